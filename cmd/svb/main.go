@@ -0,0 +1,319 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command svb encodes, decodes and inspects Stream VByte containers, as made
+// by svb.Marshal. It reads from stdin and writes to stdout.
+//
+// Usage:
+//
+//	svb encode [-in text|raw] [-format legacy|reference] [-variant 1234|0124] [-delta] [-zigzag] [-checksum]
+//	svb decode [-out text|raw]
+//	svb inspect
+//
+// Text input is made up of decimal values separated by whitespace or
+// commas, so CSV works as is. Raw input and output are little-endian
+// uint32s. With -zigzag, text values are int32s, and may be negative.
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/nelz9999/stream-vbyte-go/svb"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintf(os.Stderr, "svb: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+const usage = `usage: svb <command> [flags]
+
+commands:
+  encode   encode text or raw values from stdin into a container
+  decode   decode a container from stdin into text or raw values
+  inspect  describe the container on stdin
+`
+
+var errUsage = errors.New("unknown command")
+
+// run carries out the command given by args, so that it can be tested
+// without a process of its own.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return errUsage
+	}
+	fs := flag.NewFlagSet("svb "+args[0], flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	switch args[0] {
+	case "encode":
+		in := fs.String("in", "text", "input `layout`: text or raw")
+		format := fs.String("format", "legacy", "wire `format`: legacy or reference")
+		variant := fs.String("variant", "1234", "length `variant`: 1234 or 0124")
+		var opts svb.Options
+		fs.BoolVar(&opts.Delta, "delta", false, "store differences between values")
+		fs.BoolVar(&opts.Zigzag, "zigzag", false, "treat values as int32s, and zigzag map them")
+		fs.BoolVar(&opts.Checksum, "checksum", false, "add a CRC32C checksum")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		var err error
+		if opts.Format, err = parseFormat(*format); err != nil {
+			return err
+		}
+		if opts.Variant, err = parseVariant(*variant); err != nil {
+			return err
+		}
+		return encode(stdin, stdout, *in, opts)
+
+	case "decode":
+		out := fs.String("out", "text", "output `layout`: text or raw")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		return decode(stdin, stdout, *out)
+
+	case "inspect":
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		return inspect(stdin, stdout)
+	}
+	fmt.Fprint(stderr, usage)
+	return errUsage
+}
+
+func parseFormat(s string) (svb.Format, error) {
+	for _, f := range []svb.Format{svb.Legacy, svb.Reference} {
+		if s == f.String() {
+			return f, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown format %q", s)
+}
+
+func parseVariant(s string) (svb.Variant, error) {
+	for _, v := range []svb.Variant{svb.Variant1234, svb.Variant0124} {
+		if s == v.String() {
+			return v, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown variant %q", s)
+}
+
+func encode(r io.Reader, w io.Writer, in string, opts svb.Options) error {
+	var vals []uint32
+	var err error
+	switch in {
+	case "text":
+		vals, err = readText(r, opts.Zigzag)
+	case "raw":
+		vals, err = readRaw(r)
+	default:
+		err = fmt.Errorf("unknown input layout %q", in)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(svb.Marshal(nil, vals, opts))
+	return err
+}
+
+// readText parses decimal values separated by whitespace or commas.
+func readText(r io.Reader, signed bool) ([]uint32, error) {
+	sc := bufio.NewScanner(r)
+	sc.Split(bufio.ScanWords)
+	var vals []uint32
+	for sc.Scan() {
+		for _, field := range strings.Split(sc.Text(), ",") {
+			if field == "" {
+				continue
+			}
+			var v uint64
+			var err error
+			if signed {
+				var n int64
+				n, err = strconv.ParseInt(field, 10, 32)
+				v = uint64(uint32(n))
+			} else {
+				v, err = strconv.ParseUint(field, 10, 32)
+			}
+			if err != nil {
+				return nil, err
+			}
+			vals = append(vals, uint32(v))
+		}
+	}
+	return vals, sc.Err()
+}
+
+// readRaw reads little-endian uint32s.
+func readRaw(r io.Reader) ([]uint32, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(b)%4 != 0 {
+		return nil, fmt.Errorf("raw input is %d bytes, which is not a whole number of uint32s", len(b))
+	}
+	vals := make([]uint32, len(b)/4)
+	for ix := range vals {
+		vals[ix] = binary.LittleEndian.Uint32(b[4*ix:])
+	}
+	return vals, nil
+}
+
+func decode(r io.Reader, w io.Writer, out string) error {
+	blob, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	vals, opts, err := svb.Unmarshal(nil, blob)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	switch out {
+	case "text":
+		for _, v := range vals {
+			if opts.Zigzag {
+				fmt.Fprintln(bw, int32(v))
+			} else {
+				fmt.Fprintln(bw, v)
+			}
+		}
+	case "raw":
+		var b [4]byte
+		for _, v := range vals {
+			binary.LittleEndian.PutUint32(b[:], v)
+			bw.Write(b[:])
+		}
+	default:
+		return fmt.Errorf("unknown output layout %q", out)
+	}
+	return bw.Flush()
+}
+
+// inspect describes the container on r. The sizes and histograms come from
+// the bytes as they are stored, so that a container that doesn't decode can
+// still be looked at; only the comparison of the modes needs the values.
+func inspect(r io.Reader, w io.Writer) error {
+	blob, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	opts, count, body, err := svb.UnmarshalHeader(blob)
+	if err != nil {
+		return err
+	}
+	clen := (count + 3) / 4
+	ctrl, data := body[:clen], body[clen:]
+
+	var s *svb.Stats
+	var problem error
+	if opts.Variant == svb.Variant1234 {
+		s, problem = svb.AnalyzeEncoded(ctrl, data)
+		if problem == nil && s.Count != count {
+			problem = fmt.Errorf("data section holds %d values, not %d", s.Count, count)
+		}
+	}
+	if s == nil || problem != nil {
+		s = storedStats(count, ctrl, data)
+	}
+
+	fmt.Fprintf(w, "values:     %d\n", count)
+	fmt.Fprintf(w, "format:     %v\n", opts.Format)
+	fmt.Fprintf(w, "variant:    %v\n", opts.Variant)
+	fmt.Fprintf(w, "delta:      %t\n", opts.Delta)
+	fmt.Fprintf(w, "zigzag:     %t\n", opts.Zigzag)
+	fmt.Fprintf(w, "checksum:   %t\n", opts.Checksum)
+	fmt.Fprintf(w, "size:       %d bytes (%d control, %d data)\n", len(blob), len(ctrl), len(data))
+	if problem != nil {
+		fmt.Fprintf(w, "problem:    %v\n", problem)
+	}
+	if count == 0 {
+		return nil
+	}
+	fmt.Fprintf(w, "bytes/int:  %.3f\n", float64(len(blob))/float64(count))
+
+	blens := [4]int{1, 2, 3, 4}
+	if opts.Variant == svb.Variant0124 {
+		blens = [4]int{0, 1, 2, 4}
+	}
+	fmt.Fprintf(w, "lengths:\n")
+	for c, n := range s.Lengths {
+		fmt.Fprintf(w, "  %d bytes  %8d  %5.1f%%\n", blens[c], n, 100*float64(n)/float64(count))
+	}
+
+	vals, _, err := svb.Unmarshal(nil, blob)
+	if err != nil {
+		fmt.Fprintf(w, "decode:     %v\n", err)
+	} else {
+		a := svb.Analyze(vals, opts)
+		fmt.Fprintf(w, "bytes/int by mode:\n")
+		fmt.Fprintf(w, "  plain         %.3f\n", a.Modes.Plain)
+		fmt.Fprintf(w, "  delta         %.3f\n", a.Modes.Delta)
+		fmt.Fprintf(w, "  zigzag        %.3f\n", a.Modes.Zigzag)
+		fmt.Fprintf(w, "  delta+zigzag  %.3f\n", a.Modes.DeltaZigzag)
+		fmt.Fprintf(w, "recommended:  -delta=%t -zigzag=%t\n", a.Recommended.Delta, a.Recommended.Zigzag)
+	}
+
+	var seen []int
+	for c, n := range s.Control {
+		if n > 0 {
+			seen = append(seen, c)
+		}
+	}
+	sort.SliceStable(seen, func(i, j int) bool {
+		return s.Control[seen[i]] > s.Control[seen[j]]
+	})
+	fmt.Fprintf(w, "control bytes:\n")
+	for _, c := range seen {
+		fmt.Fprintf(w, "  0x%02x  %8d  %5.1f%%\n", c, s.Control[c], 100*float64(s.Control[c])/float64(clen))
+	}
+	return nil
+}
+
+// storedStats counts the length codes and control bytes of count values
+// straight from the stored control section, for when AnalyzeEncoded can't
+// be used. It works for either variant, since the unused slots of a
+// trailing partial quad always have code 0.
+func storedStats(count int, ctrl, data []byte) *svb.Stats {
+	s := &svb.Stats{Count: count, Size: len(ctrl) + len(data)}
+	for _, c := range ctrl {
+		s.Control[c]++
+		for i := uint(0); i < 4; i++ {
+			s.Lengths[c>>(2*i)&0x03]++
+		}
+	}
+	pad := 4*len(ctrl) - count
+	if pad > s.Lengths[0] {
+		pad = s.Lengths[0]
+	}
+	s.Lengths[0] -= pad
+	return s
+}
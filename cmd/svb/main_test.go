@@ -0,0 +1,125 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		args  []string
+		input string
+		text  string
+	}{
+		{[]string{"encode"}, "1,2,3\n300 70000\n", "1\n2\n3\n300\n70000\n"},
+		{[]string{"encode", "-delta", "-checksum"}, "5 10 15 2000", "5\n10\n15\n2000\n"},
+		{[]string{"encode", "-zigzag", "-format", "reference"}, "-1, 7, -70000", "-1\n7\n-70000\n"},
+		{[]string{"encode", "-variant", "0124"}, "0 0 1 65536", "0\n0\n1\n65536\n"},
+		{[]string{"encode", "-in", "raw"}, "\x01\x00\x00\x00\x00\x01\x00\x00", "1\n256\n"},
+		{[]string{"encode"}, "", ""},
+	}
+
+	for _, test := range tests {
+		var blob, stderr bytes.Buffer
+		if err := run(test.args, strings.NewReader(test.input), &blob, &stderr); err != nil {
+			t.Errorf("%v: %v\n", test.args, err)
+			continue
+		}
+		encoded := blob.Bytes()
+
+		var text bytes.Buffer
+		if err := run([]string{"decode"}, bytes.NewReader(encoded), &text, &stderr); err != nil {
+			t.Errorf("%v: %v\n", test.args, err)
+		}
+		if text.String() != test.text {
+			t.Errorf("%v: %q != %q\n", test.args, text.String(), test.text)
+		}
+
+		var info bytes.Buffer
+		if err := run([]string{"inspect"}, bytes.NewReader(encoded), &info, &stderr); err != nil {
+			t.Errorf("%v: %v\n", test.args, err)
+		}
+		if !strings.HasPrefix(info.String(), "values:") {
+			t.Errorf("%v: %q\n", test.args, info.String())
+		}
+	}
+}
+
+func TestInspectShortData(t *testing.T) {
+	var blob, stderr bytes.Buffer
+	run([]string{"encode"}, strings.NewReader("1 256 70000"), &blob, &stderr)
+	encoded := blob.Bytes()
+
+	var info bytes.Buffer
+	if err := run([]string{"inspect"}, bytes.NewReader(encoded), &info, &stderr); err != nil {
+		t.Fatalf("%v\n", err)
+	}
+	if out := info.String(); !strings.Contains(out, "recommended:") || strings.Contains(out, "problem:") {
+		t.Errorf("%q\n", out)
+	}
+
+	// Dropping the last data byte leaves a header that checks out, but a
+	// stream that doesn't decode; inspect still describes what is there.
+	info.Reset()
+	short := encoded[:len(encoded)-1]
+	if err := run([]string{"inspect"}, bytes.NewReader(short), &info, &stderr); err != nil {
+		t.Fatalf("%v\n", err)
+	}
+	out := info.String()
+	for _, expected := range []string{"values:     3\n", "problem:", "decode:", "control bytes:"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("%q not in %q\n", expected, out)
+		}
+	}
+	if strings.Contains(out, "recommended:") {
+		t.Errorf("%q\n", out)
+	}
+}
+
+func TestRawOutput(t *testing.T) {
+	var blob, raw, stderr bytes.Buffer
+	run([]string{"encode"}, strings.NewReader("1 256"), &blob, &stderr)
+	if err := run([]string{"decode", "-out", "raw"}, &blob, &raw, &stderr); err != nil {
+		t.Fatalf("%v\n", err)
+	}
+	if expected := "\x01\x00\x00\x00\x00\x01\x00\x00"; raw.String() != expected {
+		t.Errorf("%q != %q\n", raw.String(), expected)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	for _, test := range []struct {
+		args  []string
+		input string
+	}{
+		{nil, ""},
+		{[]string{"bogus"}, ""},
+		{[]string{"encode", "-format", "bogus"}, ""},
+		{[]string{"encode", "-variant", "bogus"}, ""},
+		{[]string{"encode", "-in", "bogus"}, ""},
+		{[]string{"encode"}, "1 -2"},
+		{[]string{"encode", "-in", "raw"}, "\x01\x00"},
+		{[]string{"decode"}, "not a container"},
+		{[]string{"inspect"}, ""},
+	} {
+		var stdout, stderr bytes.Buffer
+		if err := run(test.args, strings.NewReader(test.input), &stdout, &stderr); err == nil {
+			t.Errorf("%v %q: expected an error\n", test.args, test.input)
+		}
+	}
+}
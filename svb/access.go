@@ -0,0 +1,65 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// ValueAt returns the i-th value of a stream encoded by Encode, where ctrl
+// and data are the control and data sections of that stream. Only the
+// target value is decoded; the position of its data is found by adding up
+// the lengths that the control bytes before it describe.
+//
+// This doesn't work for streams made by EncodeDelta, where each value
+// depends on all of the ones before it. Panics will be thrown if i is out
+// of range, or if data is too short.
+func ValueAt(ctrl, data []byte, i int) uint32 {
+	return Legacy.ValueAt(ctrl, data, i)
+}
+
+// ValueAt is the Format-specific version of the package-level ValueAt.
+func (f Format) ValueAt(ctrl, data []byte, i int) uint32 {
+	c := ctrl[i/4]
+	n := skipData(ctrl[:i/4]) + int(f.offsets()[c][i%4])
+	blen := f.table()[c][i%4]
+	if f == Reference {
+		num, _ := getLittle(data, n, blen)
+		return num
+	}
+	num, _ := getBig(data, n, blen)
+	return num
+}
+
+// skipData returns the number of data bytes described by the control bytes
+// in ctrl, which is the same in both formats.
+//
+// Every value takes 1 byte plus its 2-bit code, so this is 4 bytes per
+// control byte plus the sum of all the codes. Eight control bytes at a time
+// are treated as a uint64, where the sum of the codes is the count of low
+// bits that are set, plus twice the count of high bits that are set.
+func skipData(ctrl []byte) int {
+	n := 4 * len(ctrl)
+	for len(ctrl) >= 8 {
+		x := binary.LittleEndian.Uint64(ctrl)
+		n += bits.OnesCount64(x&0x5555555555555555) + 2*bits.OnesCount64(x&0xaaaaaaaaaaaaaaaa)
+		ctrl = ctrl[8:]
+	}
+	for _, c := range ctrl {
+		n += int(lookupTotal[c]) - 4
+	}
+	return n
+}
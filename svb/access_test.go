@@ -0,0 +1,65 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestSkipData(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for size := 0; size < 40; size++ {
+		ctrl := make([]byte, size)
+		r.Read(ctrl)
+
+		var expected int
+		for _, c := range ctrl {
+			expected += int(lookupTotal[c])
+		}
+		if n := skipData(ctrl); n != expected {
+			t.Errorf("% x: %d != %d\n", ctrl, n, expected)
+		}
+	}
+}
+
+func TestValueAt(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	src := make([]uint32, 301)
+	for ix := range src {
+		src[ix] = r.Uint32() >> uint(8*r.Intn(4))
+	}
+
+	clen := (len(src) + 3) / 4
+	for _, f := range []Format{Legacy, Reference} {
+		encoded := f.Encode(nil, src)
+		ctrl, data := encoded[:clen], encoded[clen:]
+		for ix := range src {
+			if num := f.ValueAt(ctrl, data, ix); num != src[ix] {
+				t.Errorf("%v %d: %d != %d\n", f, ix, num, src[ix])
+			}
+		}
+	}
+}
+
+func TestValueAtPanic(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("no panic received")
+		}
+	}()
+	ValueAt([]byte{0x00}, []byte{0x01, 0x02, 0x03, 0x04}, 4)
+}
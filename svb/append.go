@@ -0,0 +1,71 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+// AppendUint32s encodes vals, appending the control bytes to ctrl and the
+// data bytes to data, and returns the extended buffers. The buffers are
+// grown as needed, in the manner of the built-in append.
+//
+// Every call starts a new control byte, so when a stream is built up over
+// several calls, only the last one should pass a number of values that
+// isn't a multiple of 4.
+func AppendUint32s(ctrl, data []byte, vals ...uint32) ([]byte, []byte) {
+	return Legacy.AppendUint32s(ctrl, data, vals...)
+}
+
+// AppendUint32s is the Format-specific version of the package-level
+// AppendUint32s.
+func (f Format) AppendUint32s(ctrl, data []byte, vals ...uint32) ([]byte, []byte) {
+	data = grow(data, 4*len(vals))
+	n := len(data)
+	data = data[:cap(data)]
+	for ix := 0; ix < len(vals); ix += 4 {
+		var c byte
+		var size int
+		if len(vals)-ix >= 4 {
+			c, size = f.PutU32Block(data[n:], vals[ix:ix+4], false)
+		} else {
+			c, size = f.putPartial(data[n:], vals[ix:])
+		}
+		ctrl = append(ctrl, c)
+		n += size
+	}
+	return ctrl, data[:n]
+}
+
+// AppendEncode appends the encoding of src, as produced by Encode, to dst
+// and returns the extended buffer.
+func AppendEncode(dst []byte, src []uint32) []byte {
+	return Legacy.AppendEncode(dst, src)
+}
+
+// AppendEncode is the Format-specific version of the package-level
+// AppendEncode.
+func (f Format) AppendEncode(dst []byte, src []uint32) []byte {
+	dst = grow(dst, MaxEncodedLen(len(src)))
+	out := f.Encode(dst[len(dst):], src)
+	return dst[:len(dst)+len(out)]
+}
+
+// grow makes sure that there is room for at least n more bytes after the
+// end of b, reallocating if needed.
+func grow(b []byte, n int) []byte {
+	if cap(b)-len(b) >= n {
+		return b
+	}
+	nb := make([]byte, len(b), 2*cap(b)+n)
+	copy(nb, b)
+	return nb
+}
@@ -0,0 +1,59 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAppendUint32s(t *testing.T) {
+	src := []uint32{1024, 12, 10, 1073741824, 1, 2, 3, 1024, 65536, 7}
+	encoded := Encode(nil, src)
+	clen := (len(src) + 3) / 4
+
+	// Built up a quad at a time, from nothing.
+	var ctrl, data []byte
+	ctrl, data = AppendUint32s(ctrl, data, src[0:4]...)
+	ctrl, data = AppendUint32s(ctrl, data, src[4:8]...)
+	ctrl, data = AppendUint32s(ctrl, data, src[8:]...)
+	if !bytes.Equal(ctrl, encoded[:clen]) {
+		t.Errorf("ctrl: % x != % x\n", ctrl, encoded[:clen])
+	}
+	if !bytes.Equal(data, encoded[clen:]) {
+		t.Errorf("data: % x != % x\n", data, encoded[clen:])
+	}
+}
+
+func TestAppendEncode(t *testing.T) {
+	src := []uint32{1, 2, 3, 1024, 65536, 7}
+	prefix := []byte{0xde, 0xad}
+	for _, f := range []Format{Legacy, Reference} {
+		expected := append(append([]byte{}, prefix...), f.Encode(nil, src)...)
+
+		out := f.AppendEncode(append([]byte{}, prefix...), src)
+		if !bytes.Equal(out, expected) {
+			t.Errorf("%v: % x != % x\n", f, out, expected)
+		}
+
+		// With plenty of room, the buffer is extended in place.
+		buf := make([]byte, 2, 64)
+		copy(buf, prefix)
+		out = f.AppendEncode(buf, src)
+		if !bytes.Equal(out, expected) || &out[0] != &buf[0] {
+			t.Errorf("%v: % x != % x\n", f, out, expected)
+		}
+	}
+}
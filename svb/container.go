@@ -0,0 +1,364 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+)
+
+// The container layout is: the magic bytes, a version byte, a flags byte,
+// then the value count and the number of control bytes as uvarints,
+// followed by the control and data bytes themselves. With the checksum flag,
+// a little-endian CRC32C of the control and data bytes comes last.
+const (
+	containerMagic   = "SVB"
+	containerVersion = 1
+
+	flagDelta     = 1 << 0
+	flagZigzag    = 1 << 1
+	flagVariant   = 1 << 2
+	flagReference = 1 << 3
+	flagChecksum  = 1 << 4
+	knownFlags    = flagDelta | flagZigzag | flagVariant | flagReference | flagChecksum
+
+	checksumLen = 4
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+var (
+	// ErrNotContainer is returned by Unmarshal when its input doesn't start
+	// with the container magic bytes.
+	ErrNotContainer = errors.New("svb: not a stream vbyte container")
+
+	// ErrVersion is returned by Unmarshal for a container version (or
+	// flags) that this package doesn't know about.
+	ErrVersion = errors.New("svb: unsupported container version")
+
+	// ErrCorruptContainer is returned by Unmarshal when the container
+	// header doesn't match what follows it.
+	ErrCorruptContainer = errors.New("svb: corrupt container")
+
+	// ErrChecksum is returned by Unmarshal when the control and data bytes
+	// of a container don't match its checksum.
+	ErrChecksum = errors.New("svb: checksum mismatch")
+)
+
+// Variant selects the set of byte lengths the 2-bit codes stand for.
+type Variant uint8
+
+const (
+	// Variant1234 is the standard set of lengths: 1, 2, 3 or 4 bytes.
+	Variant1234 Variant = iota
+
+	// Variant0124 uses lengths of 0, 1, 2 or 4 bytes, as with Encode0124.
+	Variant0124
+)
+
+// String returns the name of the variant.
+func (v Variant) String() string {
+	switch v {
+	case Variant1234:
+		return "1234"
+	case Variant0124:
+		return "0124"
+	}
+	return "unknown"
+}
+
+// Options describes how the values in a container are encoded.
+type Options struct {
+	Format  Format
+	Variant Variant
+
+	// Delta stores each value as its difference from the one before it,
+	// starting from zero, as with EncodeDelta.
+	Delta bool
+
+	// Zigzag treats the values as the bits of int32s, and zigzag maps them
+	// (or with Delta, the signed differences between them) before they are
+	// stored, as with EncodeInt32.
+	Zigzag bool
+
+	// Checksum adds a CRC32C of the control and data bytes, so that
+	// corruption is caught instead of misaligning every value after it.
+	Checksum bool
+}
+
+// flags returns the header flags for the options.
+func (o Options) flags() byte {
+	var flags byte
+	if o.Delta {
+		flags |= flagDelta
+	}
+	if o.Zigzag {
+		flags |= flagZigzag
+	}
+	if o.Variant == Variant0124 {
+		flags |= flagVariant
+	}
+	if o.Format == Reference {
+		flags |= flagReference
+	}
+	if o.Checksum {
+		flags |= flagChecksum
+	}
+	return flags
+}
+
+// optionsFromFlags is the inverse of Options.flags.
+func optionsFromFlags(flags byte) Options {
+	o := Options{
+		Delta:    flags&flagDelta != 0,
+		Zigzag:   flags&flagZigzag != 0,
+		Checksum: flags&flagChecksum != 0,
+	}
+	if flags&flagVariant != 0 {
+		o.Variant = Variant0124
+	}
+	if flags&flagReference != 0 {
+		o.Format = Reference
+	}
+	return o
+}
+
+// Marshal encodes src into a self-describing container, which records the
+// options and the number of values, so that Unmarshal needs nothing else to
+// decode it. As with Encode, the storage of dst is reused when it is large
+// enough.
+func Marshal(dst []byte, src []uint32, opts Options) []byte {
+	return marshal(dst, src, opts, 1)
+}
+
+// marshal builds the container, spreading the encoding over the given
+// number of workers.
+func marshal(dst []byte, src []uint32, opts Options, workers int) []byte {
+	clen := (len(src) + 3) / 4
+	var head [len(containerMagic) + 2 + 2*binary.MaxVarintLen64]byte
+	h := copy(head[:], containerMagic)
+	head[h], head[h+1] = containerVersion, opts.flags()
+	h += 2
+	h += binary.PutUvarint(head[h:], uint64(len(src)))
+	h += binary.PutUvarint(head[h:], uint64(clen))
+
+	if max := h + MaxEncodedLen(len(src)) + checksumLen; cap(dst) < max {
+		dst = make([]byte, max)
+	} else {
+		dst = dst[:max]
+	}
+	copy(dst, head[:h])
+	// Room for the whole body has been made, so the encoders won't need to
+	// allocate.
+	body := opts.encodeParallel(dst[h:h], src, 0, workers)
+	dst = dst[:h+len(body)]
+	if opts.Checksum {
+		dst = dst[:len(dst)+checksumLen]
+		binary.LittleEndian.PutUint32(dst[len(dst)-checksumLen:], crc32.Checksum(body, castagnoli))
+	}
+	return dst
+}
+
+// encode encodes src in the bare layout the options call for. With Delta,
+// prev stands in for the value before src[0].
+func (o Options) encode(dst []byte, src []uint32, prev uint32) []byte {
+	clen := (len(src) + 3) / 4
+	if max := MaxEncodedLen(len(src)); cap(dst) < max {
+		dst = make([]byte, max)
+	} else {
+		dst = dst[:max]
+	}
+	n := o.encodeTo(dst[:clen], dst[clen:], src, prev)
+	return dst[:clen+n]
+}
+
+// encodeTo is the Options version of Format.encodeTo: it fills in ctrl and
+// the front of data, and returns the number of data bytes.
+func (o Options) encodeTo(ctrl, data []byte, src []uint32, prev uint32) int {
+	f := o.Format
+	if o.Variant == Variant1234 && !o.Zigzag {
+		return f.encodeTo(ctrl, data, src, o.Delta, prev)
+	}
+
+	// Otherwise the values are transformed a quad at a time. The unused
+	// slots of a trailing partial quad are zeros, which the 0124 variant
+	// stores in no bytes at all.
+	var n int
+	var quad [4]uint32
+	for ix := 0; ix < len(src); ix += 4 {
+		k := copy(quad[:], src[ix:])
+		for jx := range quad {
+			if jx >= k {
+				quad[jx] = 0
+				continue
+			}
+			quad[jx], prev = o.transform(quad[jx], prev)
+		}
+		var c byte
+		var size int
+		switch {
+		case o.Variant == Variant0124:
+			c, size = f.PutU32Block0124(data[n:], quad[:], false)
+		case k == 4:
+			c, size = f.PutU32Block(data[n:], quad[:], false)
+		default:
+			c, size = f.putPartial(data[n:], quad[:k])
+		}
+		ctrl[ix/4] = c
+		n += size
+	}
+	return n
+}
+
+// transform turns num into the value that is actually stored, given the
+// value before it, and returns that along with the new value before.
+func (o Options) transform(num, prev uint32) (uint32, uint32) {
+	next := num
+	if o.Delta {
+		num -= prev
+	}
+	if o.Zigzag {
+		num = zigzag(int32(num))
+	}
+	return num, next
+}
+
+// encodedDataLen is the number of data bytes that encoding src with the
+// options takes, without encoding it. With Delta, prev stands in for the
+// value before src[0].
+func (o Options) encodedDataLen(src []uint32, prev uint32) int {
+	if o.Variant == Variant1234 && !o.Delta && !o.Zigzag {
+		return EncodedLen(src) - (len(src)+3)/4
+	}
+	var n int
+	for _, num := range src {
+		num, prev = o.transform(num, prev)
+		_, size := o.Variant.code(num)
+		n += size
+	}
+	return n
+}
+
+// Unmarshal decodes a container made by Marshal, returning the values and
+// the options they were encoded with. As with Decode, the storage of dst is
+// reused when it is large enough.
+func Unmarshal(dst []uint32, src []byte) ([]uint32, Options, error) {
+	opts, count, body, err := UnmarshalHeader(src)
+	if err != nil {
+		return nil, opts, err
+	}
+	out, err := opts.decode(dst, body, count)
+	return out, opts, err
+}
+
+// UnmarshalHeader checks the header (and checksum) of a container made by
+// Marshal, and returns what it says along with the control and data bytes
+// that follow it, without decoding any values. The control section is the
+// first (count+3)/4 bytes of body. (The data section may still turn out to
+// be short, which only decoding will tell.)
+func UnmarshalHeader(src []byte) (opts Options, count int, body []byte, err error) {
+	if len(src) < len(containerMagic) || string(src[:len(containerMagic)]) != containerMagic {
+		return opts, 0, nil, ErrNotContainer
+	}
+	src = src[len(containerMagic):]
+	if len(src) < 2 {
+		return opts, 0, nil, ErrCorruptContainer
+	}
+	if src[0] != containerVersion || src[1]&^knownFlags != 0 {
+		return opts, 0, nil, ErrVersion
+	}
+	opts = optionsFromFlags(src[1])
+	src = src[2:]
+
+	// The cap on the count keeps the arithmetic below from overflowing;
+	// anything near it will fail the control length check anyway.
+	n, size := binary.Uvarint(src)
+	if size <= 0 || n > 1<<62 {
+		return opts, 0, nil, ErrCorruptContainer
+	}
+	src = src[size:]
+	clen, size := binary.Uvarint(src)
+	if size <= 0 || clen != (n+3)/4 {
+		return opts, 0, nil, ErrCorruptContainer
+	}
+	src = src[size:]
+	if opts.Checksum {
+		if len(src) < checksumLen {
+			return opts, 0, nil, ErrCorruptContainer
+		}
+		sum := binary.LittleEndian.Uint32(src[len(src)-checksumLen:])
+		src = src[:len(src)-checksumLen]
+		if crc32.Checksum(src, castagnoli) != sum {
+			return opts, 0, nil, ErrChecksum
+		}
+	}
+	if uint64(len(src)) < clen {
+		return opts, 0, nil, &DecodeError{Err: ErrShortControl, Index: 4 * len(src), Offset: len(src)}
+	}
+
+	count = int(n)
+	if len(src)-int(clen) > opts.dataLen(src[:clen], count) {
+		return opts, 0, nil, ErrCorruptContainer
+	}
+	return opts, count, src, nil
+}
+
+// dataLen is the number of data bytes that count values described by ctrl
+// take up in the variant the options call for.
+func (o Options) dataLen(ctrl []byte, count int) int {
+	if o.Variant == Variant1234 {
+		return o.Format.dataLen(ctrl, count)
+	}
+	table := o.Format.table0124()
+	var n int
+	for ix := 0; ix < count; ix++ {
+		n += int(table[ctrl[ix/4]][ix%4])
+	}
+	return n
+}
+
+// decode decodes count values from src, in the bare layout the options
+// call for.
+func (o Options) decode(dst []uint32, src []byte, count int) ([]uint32, error) {
+	f := o.Format
+	if o.Variant == Variant1234 && !o.Zigzag {
+		return f.decode(dst, src, count, o.Delta, 0)
+	}
+
+	var out []uint32
+	var err error
+	if o.Variant == Variant0124 {
+		out, err = f.Decode0124(dst, src, count)
+	} else {
+		out, err = f.decode(dst, src, count, false, 0)
+	}
+	if err != nil || !(o.Delta || o.Zigzag) {
+		return out, err
+	}
+
+	var prev uint32
+	for ix, num := range out {
+		if o.Zigzag {
+			num = uint32(unzigzag(num))
+		}
+		if o.Delta {
+			prev += num
+			num = prev
+		}
+		out[ix] = num
+	}
+	return out, nil
+}
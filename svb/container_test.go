@@ -0,0 +1,173 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func containerOptions() []Options {
+	var all []Options
+	for _, f := range []Format{Legacy, Reference} {
+		for _, v := range []Variant{Variant1234, Variant0124} {
+			for _, delta := range []bool{false, true} {
+				for _, zz := range []bool{false, true} {
+					for _, sum := range []bool{false, true} {
+						all = append(all, Options{Format: f, Variant: v, Delta: delta, Zigzag: zz, Checksum: sum})
+					}
+				}
+			}
+		}
+	}
+	return all
+}
+
+func TestContainerRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for _, opts := range containerOptions() {
+		for _, size := range []int{0, 1, 3, 4, 5, 100, 1001} {
+			src := make([]uint32, size)
+			for ix := range src {
+				src[ix] = r.Uint32() >> uint(8*r.Intn(4))
+			}
+
+			blob := Marshal(nil, src, opts)
+			out, got, err := Unmarshal(nil, blob)
+			if err != nil {
+				t.Errorf("%+v %d: %v\n", opts, size, err)
+				continue
+			}
+			if got != opts {
+				t.Errorf("%d: %+v != %+v\n", size, got, opts)
+			}
+			if len(out) != size || (size > 0 && !reflect.DeepEqual(out, src)) {
+				t.Errorf("%+v %d: %v != %v\n", opts, size, out, src)
+			}
+		}
+	}
+}
+
+func TestContainerLayout(t *testing.T) {
+	src := []uint32{1, 2, 3, 4, 5}
+	blob := Marshal(nil, src, Options{Delta: true})
+	expected := []byte{
+		'S', 'V', 'B', 1, flagDelta, 5, 2,
+		0x00, 0x00, // every difference fits in a byte
+		1, 1, 1, 1, 1,
+	}
+	if !reflect.DeepEqual(blob, expected) {
+		t.Errorf("% x != % x\n", blob, expected)
+	}
+
+	// The body is exactly what EncodeDelta would have made.
+	if body := EncodeDelta(nil, src, 0); !reflect.DeepEqual(blob[7:], body) {
+		t.Errorf("% x != % x\n", blob[7:], body)
+	}
+}
+
+func TestContainerZigzag(t *testing.T) {
+	src := []int32{-5, 3, -1 << 31, 1<<31 - 1, 0, -2}
+	vals := make([]uint32, len(src))
+	for ix, num := range src {
+		vals[ix] = uint32(num)
+	}
+	for _, delta := range []bool{false, true} {
+		opts := Options{Zigzag: true, Delta: delta}
+		out, _, err := Unmarshal(nil, Marshal(nil, vals, opts))
+		if err != nil || !reflect.DeepEqual(out, vals) {
+			t.Errorf("%t: %v, %v != %v\n", delta, out, err, vals)
+		}
+	}
+
+	// Without delta, this is the same as EncodeInt32.
+	blob := Marshal(nil, vals, Options{Zigzag: true})
+	if body := EncodeInt32(nil, src); !reflect.DeepEqual(blob[7:], body) {
+		t.Errorf("% x != % x\n", blob[7:], body)
+	}
+}
+
+func TestContainerErrors(t *testing.T) {
+	src := []uint32{1, 1 << 8, 1 << 16, 1 << 24, 7}
+	blob := Marshal(nil, src, Options{})
+
+	for ix, tc := range []struct {
+		blob []byte
+		err  error
+	}{
+		{nil, ErrNotContainer},
+		{[]byte("SVX\x01\x00\x00\x00"), ErrNotContainer},
+		{blob[:3], ErrCorruptContainer},
+		{[]byte("SVB\x02\x00\x00\x00"), ErrVersion},
+		{[]byte("SVB\x01\x80\x00\x00"), ErrVersion},
+		{[]byte("SVB\x01\x00\x05"), ErrCorruptContainer},
+		{[]byte("SVB\x01\x00\x05\x01"), ErrCorruptContainer},
+		{[]byte("SVB\x01\x00\x05\x02\x00"), ErrShortControl},
+		{blob[:len(blob)-1], ErrShortData},
+		{append(blob[:len(blob):len(blob)], 0), ErrCorruptContainer},
+	} {
+		if _, _, err := Unmarshal(nil, tc.blob); !errors.Is(err, tc.err) {
+			t.Errorf("%d: %v != %v\n", ix, err, tc.err)
+		}
+	}
+}
+
+func TestContainerChecksum(t *testing.T) {
+	src := []uint32{1, 1 << 8, 1 << 16, 1 << 24, 7}
+	blob := Marshal(nil, src, Options{Checksum: true})
+	expected := []byte{
+		'S', 'V', 'B', 1, flagChecksum, 5, 2,
+		0x1b, 0x00,
+		0x01, 0x01, 0x00, 0x01, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x07,
+	}
+	if !reflect.DeepEqual(blob[:len(blob)-4], expected) {
+		t.Errorf("% x != % x\n", blob[:len(blob)-4], expected)
+	}
+
+	// A single flipped bit anywhere past the header has to be caught.
+	for ix := 7; ix < len(blob); ix++ {
+		for bit := uint(0); bit < 8; bit++ {
+			bad := append([]byte(nil), blob...)
+			bad[ix] ^= 1 << bit
+			if _, _, err := Unmarshal(nil, bad); err != ErrChecksum {
+				t.Errorf("%d %d: %v\n", ix, bit, err)
+			}
+		}
+	}
+
+	if _, _, err := Unmarshal(nil, blob[:len(blob)-1]); err != ErrChecksum {
+		t.Errorf("truncated: %v\n", err)
+	}
+	if _, _, err := Unmarshal(nil, []byte("SVB\x01\x10\x00\x00\x00")); err != ErrCorruptContainer {
+		t.Errorf("no checksum: %v\n", err)
+	}
+}
+
+func TestUnmarshalHeader(t *testing.T) {
+	src := []uint32{1, 1 << 8, 1 << 16, 1 << 24, 7}
+	opts := Options{Format: Reference, Checksum: true}
+	blob := Marshal(nil, src, opts)
+
+	got, count, body, err := UnmarshalHeader(blob)
+	if err != nil || got != opts || count != len(src) {
+		t.Errorf("%+v, %d, %v\n", got, count, err)
+	}
+	if expected := Reference.Encode(nil, src); !reflect.DeepEqual(body, expected) {
+		t.Errorf("% x != % x\n", body, expected)
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !purego
+
+package svb
+
+// The CPU features that the assembly kernels rely on. These are set by a
+// variable initializer rather than in init, so that they are already known
+// when the kernel choices (useSSSE3 and the like) are initialized from them.
+var hasSSSE3, hasSSE41, hasAVX2 = detectCPU()
+
+func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+func xgetbv() (eax, edx uint32)
+
+func detectCPU() (ssse3, sse41, avx2 bool) {
+	maxID, _, _, _ := cpuid(0, 0)
+	if maxID < 1 {
+		return
+	}
+	_, _, ecx1, _ := cpuid(1, 0)
+	ssse3 = ecx1&(1<<9) != 0
+	sse41 = ecx1&(1<<19) != 0
+
+	// AVX2 also needs the OS to save the YMM registers on a context
+	// switch, which is what OSXSAVE and XGETBV tell us.
+	osxsave := ecx1&(1<<27) != 0
+	if maxID < 7 || !osxsave {
+		return
+	}
+	if xcr0, _ := xgetbv(); xcr0&0x06 != 0x06 {
+		return
+	}
+	_, ebx7, _, _ := cpuid(7, 0)
+	avx2 = ebx7&(1<<5) != 0
+	return
+}
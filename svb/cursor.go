@@ -0,0 +1,134 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import (
+	"io"
+	"sort"
+)
+
+// Cursor walks forward through a delta encoded stream of sorted values, as
+// made by EncodeDeltaIndexed, and can jump ahead to the first value at or
+// above a target. This is the NextGEQ operation that conjunctive queries
+// over posting lists are built on.
+//
+// The skip index gives the largest value of every block (it's the value just
+// before the next one), so NextGEQ can find the block holding its answer
+// without decoding anything, and then only decode from the start of that
+// block. With an index entry for every quad, that means only the quad
+// holding the answer gets decoded.
+type Cursor struct {
+	d     *Decoder
+	index *Index
+	cur   uint32
+	valid bool
+	err   error
+}
+
+// NewCursor returns a Cursor over the count values that EncodeDelta or
+// EncodeDeltaIndexed stored in src, where prev is the value that was given
+// to the encoder. Without an index, NextGEQ falls back to decoding every
+// value on the way to its target.
+func NewCursor(src []byte, count int, prev uint32, index *Index) (*Cursor, error) {
+	return Legacy.NewCursor(src, count, prev, index)
+}
+
+// NewCursor is the Format-specific version of the package-level NewCursor.
+func (f Format) NewCursor(src []byte, count int, prev uint32, index *Index) (*Cursor, error) {
+	d, err := f.NewDeltaDecoder(src, count, prev, index)
+	if err != nil {
+		return nil, err
+	}
+	return &Cursor{d: d, index: index}, nil
+}
+
+// Cursor returns a Cursor over the values of the list.
+func (p *PostingList) Cursor() *Cursor {
+	return &Cursor{d: p.cursor().d, index: p.index}
+}
+
+// Value returns the value the cursor is on. It is only meaningful after
+// Next or NextGEQ has returned true.
+func (c *Cursor) Value() uint32 {
+	return c.cur
+}
+
+// Pos returns the position in the stream of the value the cursor is on, or
+// -1 before the first call to Next or NextGEQ.
+func (c *Cursor) Pos() int {
+	return c.d.Pos() - 1
+}
+
+// Err returns the error, if any, that stopped the cursor early. Running off
+// the end of the stream is not an error.
+func (c *Cursor) Err() error {
+	return c.err
+}
+
+// Next moves to the next value, returning it. At the end of the stream (or
+// on an error) it returns false.
+func (c *Cursor) Next() (uint32, bool) {
+	if c.err != nil {
+		return 0, false
+	}
+	v, err := c.d.ReadUint32()
+	if err != nil {
+		if err != io.EOF {
+			c.err = err
+		}
+		c.valid = false
+		return 0, false
+	}
+	c.cur, c.valid = v, true
+	return v, true
+}
+
+// NextGEQ moves to the first value that is no smaller than target,
+// returning it. The cursor never moves backwards, so if it is already on
+// such a value, it stays put. When there is no such value, it returns
+// false, and the cursor is left at the end of the stream.
+func (c *Cursor) NextGEQ(target uint32) (uint32, bool) {
+	if c.valid && c.cur >= target {
+		return c.cur, true
+	}
+	if c.index != nil && c.err == nil {
+		c.skip(target)
+	}
+	for {
+		v, ok := c.Next()
+		if !ok || v >= target {
+			return v, ok
+		}
+	}
+}
+
+// skip jumps ahead to the start of the last block where the value before
+// it is smaller than target, if that's past where the cursor is now. Every
+// earlier block ends below target, so the answer to NextGEQ can't be in
+// them.
+func (c *Cursor) skip(target uint32) {
+	bases := c.index.Bases
+	k := sort.Search(len(bases), func(k int) bool {
+		return bases[k] >= target
+	}) - 1
+	start := 4 * k * c.index.Interval
+	if k < 1 || start <= c.d.Pos() {
+		return
+	}
+	if err := c.d.Seek(start); err != nil {
+		c.err = err
+	}
+	c.valid = false
+}
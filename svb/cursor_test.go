@@ -0,0 +1,110 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestCursorNextGEQ(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	vals := randomSet(r, 333, 10, 5000)
+
+	for _, f := range []Format{Legacy, Reference} {
+		for _, interval := range []int{-1, 1, 3, 100} {
+			var c *Cursor
+			var err error
+			if interval < 0 {
+				c, err = f.NewCursor(f.EncodeDelta(nil, vals, 0), len(vals), 0, nil)
+			} else {
+				encoded, idx := f.EncodeDeltaIndexed(nil, vals, 0, interval)
+				c, err = f.NewCursor(encoded, len(vals), 0, idx)
+			}
+			if err != nil {
+				t.Fatalf("%v %d: %v\n", f, interval, err)
+			}
+			if c.Pos() != -1 {
+				t.Errorf("%v %d: pos %d\n", f, interval, c.Pos())
+			}
+
+			// Targets only go up, but sometimes stay put or fall behind
+			// the current value.
+			var target uint32
+			for target < 5100 {
+				target += uint32(r.Intn(60))
+				if r.Intn(4) == 0 && target > 30 {
+					target -= 30
+				}
+				ix := sort.Search(len(vals), func(ix int) bool {
+					return vals[ix] >= target
+				})
+				if p := c.Pos(); p > ix {
+					ix = p
+				}
+
+				v, ok := c.NextGEQ(target)
+				if ix == len(vals) {
+					if ok {
+						t.Errorf("%v %d %d: unexpected %d\n", f, interval, target, v)
+					}
+					break
+				}
+				if !ok || v != vals[ix] || c.Value() != v || c.Pos() != ix {
+					t.Errorf("%v %d %d: %d, %t, %d != %d, %d\n", f, interval, target, v, ok, c.Pos(), vals[ix], ix)
+				}
+			}
+			if c.Err() != nil {
+				t.Errorf("%v %d: %v\n", f, interval, c.Err())
+			}
+		}
+	}
+}
+
+func TestCursorNext(t *testing.T) {
+	vals := []uint32{3, 9, 27, 81, 243}
+	p, _ := NewPostingList(vals)
+	c := p.Cursor()
+	for ix, expected := range vals {
+		if v, ok := c.Next(); !ok || v != expected {
+			t.Errorf("%d: %d, %t != %d\n", ix, v, ok, expected)
+		}
+	}
+	if _, ok := c.Next(); ok {
+		t.Errorf("expected end of stream\n")
+	}
+
+	c = p.Cursor()
+	if v, ok := c.NextGEQ(80); !ok || v != 81 {
+		t.Errorf("%d, %t != 81\n", v, ok)
+	}
+	if v, ok := c.Next(); !ok || v != 243 {
+		t.Errorf("%d, %t != 243\n", v, ok)
+	}
+}
+
+func TestCursorShortData(t *testing.T) {
+	vals := []uint32{1, 1 << 8, 1 << 16, 1 << 24, 1<<24 + 1}
+	encoded := EncodeDelta(nil, vals, 0)
+	c, err := NewCursor(encoded[:len(encoded)-1], len(vals), 0, nil)
+	if err != nil {
+		t.Fatalf("%v\n", err)
+	}
+	if _, ok := c.NextGEQ(1 << 30); ok || c.Err() == nil {
+		t.Errorf("%t, %v\n", ok, c.Err())
+	}
+}
@@ -14,41 +14,52 @@
 
 package svb
 
-import "io"
+import (
+	"encoding/binary"
+	"io"
+)
 
 // Uint32s decodes a quad of uint32 from the data buffer, returning
 // the four uint32s and the number of bytes consumed from the buffer.
 // If there aren't enough bytes in the data buffer to match what is required
 // by the ctrl byte, the quad is returned as zeros with n = 0.
 func Uint32s(ctrl byte, data []byte) (nums [4]uint32, n int) {
-	blens := lookup[ctrl]
-	if len(data) < int(blens[0]+blens[1]+blens[2]+blens[3]) {
+	return Legacy.Uint32s(ctrl, data)
+}
+
+// Uint32s is the Format-specific version of the package-level Uint32s.
+func (f Format) Uint32s(ctrl byte, data []byte) (nums [4]uint32, n int) {
+	if len(data) < int(lookupTotal[ctrl]) {
 		return nums, 0
 	}
-	for ix, blen := range blens {
-		for jx := uint8(0); jx < blen; jx++ {
-			nums[ix] <<= 8
-			nums[ix] |= uint32(data[n])
-			n++
-		}
-	}
-	return nums, n
+	return f.GetU32Block(ctrl, data, false)
 }
 
 // ReadUint32s reads a quad of uint32 from d, using the information encoded
-// in the ctrl byte.
+// in the ctrl byte. If d runs out partway through the quad, the values read
+// so far are returned along with io.ErrUnexpectedEOF.
 func ReadUint32s(ctrl byte, d io.ByteReader) (nums [4]uint32, err error) {
-	blens := lookup[ctrl]
-	var n int
+	return Legacy.ReadUint32s(ctrl, d)
+}
+
+// ReadUint32s is the Format-specific version of the package-level
+// ReadUint32s.
+func (f Format) ReadUint32s(ctrl byte, d io.ByteReader) (nums [4]uint32, err error) {
+	blens := f.table()[ctrl]
 	for ix, blen := range blens {
 		for jx := uint8(0); jx < blen; jx++ {
 			b, err := d.ReadByte()
-			if err != nil {
+			if err == io.EOF && (ix > 0 || jx > 0) {
+				return nums, io.ErrUnexpectedEOF
+			} else if err != nil {
 				return nums, err
 			}
-			nums[ix] <<= 8
-			nums[ix] |= uint32(b)
-			n++
+			if f == Reference {
+				nums[ix] |= uint32(b) << (8 * jx)
+			} else {
+				nums[ix] <<= 8
+				nums[ix] |= uint32(b)
+			}
 		}
 	}
 	return nums, nil
@@ -70,22 +81,39 @@ func ReadUint32s(ctrl byte, d io.ByteReader) (nums [4]uint32, err error) {
 // Panics will be thrown if there are too few bytes available in the data
 // buffer.
 func GetU32Block(ctrl byte, data []byte, diff bool) (quad [4]uint32, n int) {
-	blens := lookup[ctrl]
+	return Legacy.GetU32Block(ctrl, data, diff)
+}
+
+// GetU32BlockChecked is like GetU32Block, except that it returns a
+// *DecodeError wrapping ErrShortData rather than panicking when there are
+// too few bytes available in the data buffer.
+func GetU32BlockChecked(ctrl byte, data []byte, diff bool) (quad [4]uint32, n int, err error) {
+	return Legacy.GetU32BlockChecked(ctrl, data, diff)
+}
+
+// GetU32BlockChecked is the Format-specific version of the package-level
+// GetU32BlockChecked.
+func (f Format) GetU32BlockChecked(ctrl byte, data []byte, diff bool) (quad [4]uint32, n int, err error) {
+	if len(data) < int(lookupTotal[ctrl]) {
+		blens := f.table()[ctrl]
+		return quad, 0, shortData(0, len(data), 4, func(ix int) int {
+			return int(blens[ix])
+		})
+	}
+	quad, n = f.GetU32Block(ctrl, data, diff)
+	return quad, n, nil
+}
+
+// GetU32Block is the Format-specific version of the package-level
+// GetU32Block.
+func (f Format) GetU32Block(ctrl byte, data []byte, diff bool) (quad [4]uint32, n int) {
+	blens := f.table()[ctrl]
 	for ix, blen := range blens {
-		if blen == 4 {
-			quad[ix] |= (uint32(data[n]) << 24)
-			n++
-		}
-		if blen >= 3 {
-			quad[ix] |= (uint32(data[n]) << 16)
-			n++
+		if f == Reference {
+			quad[ix], n = getLittle(data, n, blen)
+		} else {
+			quad[ix], n = getBig(data, n, blen)
 		}
-		if blen >= 2 {
-			quad[ix] |= (uint32(data[n]) << 8)
-			n++
-		}
-		quad[ix] |= uint32(data[n])
-		n++
 	}
 	if diff {
 		quad[1] += quad[0]
@@ -94,3 +122,200 @@ func GetU32Block(ctrl byte, data []byte, diff bool) (quad [4]uint32, n int) {
 	}
 	return quad, n
 }
+
+// getBig reads a blen byte, most significant byte first, value from data
+// starting at offset n, returning the value and the offset after it.
+func getBig(data []byte, n int, blen uint8) (num uint32, next int) {
+	if blen == 4 {
+		num |= (uint32(data[n]) << 24)
+		n++
+	}
+	if blen >= 3 {
+		num |= (uint32(data[n]) << 16)
+		n++
+	}
+	if blen >= 2 {
+		num |= (uint32(data[n]) << 8)
+		n++
+	}
+	num |= uint32(data[n])
+	n++
+	return num, n
+}
+
+// getLittle reads a blen byte, least significant byte first, value from data
+// starting at offset n, returning the value and the offset after it.
+func getLittle(data []byte, n int, blen uint8) (num uint32, next int) {
+	num = uint32(data[n])
+	n++
+	if blen >= 2 {
+		num |= (uint32(data[n]) << 8)
+		n++
+	}
+	if blen >= 3 {
+		num |= (uint32(data[n]) << 16)
+		n++
+	}
+	if blen == 4 {
+		num |= (uint32(data[n]) << 24)
+		n++
+	}
+	return num, n
+}
+
+// Decode decodes count values from src, which is expected to be in the
+// canonical layout produced by Encode: all of the control bytes first,
+// followed by all of the data bytes. The returned slice may be a sub-slice
+// of dst if dst was large enough to hold all of the values, otherwise a newly
+// allocated slice will be returned.
+//
+// If src is too short to hold what the control bytes describe, then a
+// *DecodeError wrapping either ErrShortControl or ErrShortData is returned.
+// Decode never panics, so it is suitable for untrusted input.
+func Decode(dst []uint32, src []byte, count int) ([]uint32, error) {
+	return Legacy.Decode(dst, src, count)
+}
+
+// Decode is the Format-specific version of the package-level Decode.
+func (f Format) Decode(dst []uint32, src []byte, count int) ([]uint32, error) {
+	return f.decode(dst, src, count, false, 0)
+}
+
+// DecodeUnchecked is the fast path of Decode, for input that is trusted. It
+// skips the up-front check that src holds everything the control bytes call
+// for, so input that is too short will cause a panic instead of an error.
+func DecodeUnchecked(dst []uint32, src []byte, count int) []uint32 {
+	return Legacy.DecodeUnchecked(dst, src, count)
+}
+
+// DecodeUnchecked is the Format-specific version of the package-level
+// DecodeUnchecked.
+func (f Format) DecodeUnchecked(dst []uint32, src []byte, count int) []uint32 {
+	return f.decodeUnchecked(dst, src, count, false, 0)
+}
+
+// decode does the work for both Decode and DecodeDelta, checking that src
+// is long enough before handing it over to decodeUnchecked.
+func (f Format) decode(dst []uint32, src []byte, count int, delta bool, prev uint32) ([]uint32, error) {
+	if count < 0 {
+		return nil, ErrInvalidCount
+	}
+	// Checking the count against src first keeps a huge count from
+	// overflowing the control length.
+	if count > 4*len(src) {
+		return nil, &DecodeError{Err: ErrShortControl, Index: 4 * len(src), Offset: len(src)}
+	}
+	clen := (count + 3) / 4
+	ctrl := src[:clen]
+	if f.dataLen(ctrl, count) > len(src)-clen {
+		table := f.table()
+		return nil, shortData(clen, len(src)-clen, count, func(ix int) int {
+			return int(table[ctrl[ix/4]][ix%4])
+		})
+	}
+	return f.decodeUnchecked(dst, src, count, delta, prev), nil
+}
+
+// dataLen is the number of data bytes that count values described by ctrl
+// take up, where ctrl holds at least enough control bytes for count values.
+func (f Format) dataLen(ctrl []byte, count int) int {
+	full := count / 4
+	n := skipData(ctrl[:full])
+	if k := count - 4*full; k > 0 {
+		blens := f.table()[ctrl[full]]
+		for _, blen := range blens[:k] {
+			n += int(blen)
+		}
+	}
+	return n
+}
+
+// decodeUnchecked does the work for both DecodeUnchecked and decode. When
+// delta is set, the decoded values are a running sum of the stored
+// differences, starting from prev.
+func (f Format) decodeUnchecked(dst []uint32, src []byte, count int, delta bool, prev uint32) []uint32 {
+	clen := (count + 3) / 4
+	if cap(dst) < count {
+		dst = make([]uint32, count)
+	} else {
+		dst = dst[:count]
+	}
+	ctrl, data := src[:clen], src[clen:]
+
+	full := count / 4
+	n := f.decodeQuads(dst[:4*full], ctrl[:full], data)
+	if k := count - 4*full; k > 0 {
+		c := ctrl[full]
+		blens := f.table()[c]
+		var need int
+		for _, blen := range blens[:k] {
+			need += int(blen)
+		}
+		quad := f.getPartial(c, data[n:n+need])
+		copy(dst[4*full:], quad[:k])
+	}
+
+	if delta {
+		for ix := range dst {
+			prev += dst[ix]
+			dst[ix] = prev
+		}
+	}
+	return dst
+}
+
+// decodeQuads decodes len(ctrl) whole quads into dst, returning the number
+// of data bytes consumed. The caller must already have checked that data
+// holds all of the bytes that the control bytes call for.
+//
+// While there are at least 16 bytes of data left, each value is read as a
+// whole 4-byte word starting at its offset (from the offset table), which
+// is then trimmed down to its length, rather than looping byte-by-byte. On
+// platforms with a vector kernel, that gets the first shot at the quads.
+func (f Format) decodeQuads(dst []uint32, ctrl, data []byte) int {
+	ix, n := f.decodeQuadsSIMD(dst, ctrl, data)
+
+	lens, offs := f.table(), f.offsets()
+	if f == Reference {
+		for ; ix < len(ctrl) && len(data)-n >= 16; ix++ {
+			c := ctrl[ix]
+			blens, boffs := &lens[c], &offs[c]
+			block, out := data[n:n+16], dst[4*ix:4*ix+4]
+			out[0] = binary.LittleEndian.Uint32(block[boffs[0]:]) & (0xffffffff >> (32 - 8*uint(blens[0])))
+			out[1] = binary.LittleEndian.Uint32(block[boffs[1]:]) & (0xffffffff >> (32 - 8*uint(blens[1])))
+			out[2] = binary.LittleEndian.Uint32(block[boffs[2]:]) & (0xffffffff >> (32 - 8*uint(blens[2])))
+			out[3] = binary.LittleEndian.Uint32(block[boffs[3]:]) & (0xffffffff >> (32 - 8*uint(blens[3])))
+			n += int(lookupTotal[c])
+		}
+	} else {
+		for ; ix < len(ctrl) && len(data)-n >= 16; ix++ {
+			c := ctrl[ix]
+			blens, boffs := &lens[c], &offs[c]
+			block, out := data[n:n+16], dst[4*ix:4*ix+4]
+			out[0] = binary.BigEndian.Uint32(block[boffs[0]:]) >> (32 - 8*uint(blens[0]))
+			out[1] = binary.BigEndian.Uint32(block[boffs[1]:]) >> (32 - 8*uint(blens[1]))
+			out[2] = binary.BigEndian.Uint32(block[boffs[2]:]) >> (32 - 8*uint(blens[2]))
+			out[3] = binary.BigEndian.Uint32(block[boffs[3]:]) >> (32 - 8*uint(blens[3]))
+			n += int(lookupTotal[c])
+		}
+	}
+
+	// The last few quads might be too close to the end of data for the
+	// word-at-a-time reads.
+	for ; ix < len(ctrl); ix++ {
+		quad, size := f.GetU32Block(ctrl[ix], data[n:], false)
+		copy(dst[4*ix:], quad[:])
+		n += size
+	}
+	return n
+}
+
+// getPartial decodes the trailing 1-3 values of a stream. The data is copied
+// into a zero-padded scratch buffer first, so that the slots which were
+// never written can be decoded without running off the end of data.
+func (f Format) getPartial(ctrl byte, data []byte) [4]uint32 {
+	var scratch [16]byte
+	copy(scratch[:], data)
+	quad, _ := f.GetU32Block(ctrl, scratch[:], false)
+	return quad
+}
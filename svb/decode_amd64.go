@@ -0,0 +1,73 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !purego
+
+package svb
+
+// shuffleMasks and refShuffleMasks hold a PSHUFB mask for every control
+// byte, which moves the data bytes of a block into the right places of four
+// little-endian uint32 lanes, and zeroes the bytes above each value's length.
+var shuffleMasks, refShuffleMasks [256][16]byte
+
+// useSSSE3 and useAVX2 pick the decoding kernel. They start out as whatever
+// the CPU supports, and are only ever changed by tests.
+var (
+	useSSSE3 = hasSSSE3
+	useAVX2  = hasAVX2 && hasSSSE3
+)
+
+func init() {
+	for ctrl := 0; ctrl < 256; ctrl++ {
+		for ix := 0; ix < 4; ix++ {
+			for b := 0; b < 4; b++ {
+				var mask, refMask byte = 0x80, 0x80
+				if blen := int(lookup[ctrl][ix]); b < blen {
+					mask = lookupOffset[ctrl][ix] + byte(blen-1-b)
+				}
+				if blen := int(refLookup[ctrl][ix]); b < blen {
+					refMask = refLookupOffset[ctrl][ix] + byte(b)
+				}
+				shuffleMasks[ctrl][4*ix+b] = mask
+				refShuffleMasks[ctrl][4*ix+b] = refMask
+			}
+		}
+	}
+}
+
+// decodeSSSE3 and decodeAVX2 decode whole quads into dst for as long as
+// there are quads left in ctrl and at least 16 (or, for AVX2, 32) bytes
+// left in data, since each block is loaded as a whole vector. They return
+// the number of quads decoded and data bytes consumed.
+func decodeSSSE3(dst *uint32, ctrl, data []byte, masks *[256][16]byte) (quads, n int)
+func decodeAVX2(dst *uint32, ctrl, data []byte, masks *[256][16]byte) (quads, n int)
+
+// decodeQuadsSIMD decodes as many of the leading quads as the vector
+// kernels can handle, leaving the rest to the caller.
+func (f Format) decodeQuadsSIMD(dst []uint32, ctrl, data []byte) (quads, n int) {
+	if len(ctrl) == 0 {
+		return 0, 0
+	}
+	masks := &shuffleMasks
+	if f == Reference {
+		masks = &refShuffleMasks
+	}
+	switch {
+	case useAVX2:
+		return decodeAVX2(&dst[0], ctrl, data, masks)
+	case useSSSE3:
+		return decodeSSSE3(&dst[0], ctrl, data, masks)
+	}
+	return 0, 0
+}
@@ -0,0 +1,144 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !purego
+
+#include "textflag.h"
+
+// Register use, for both kernels:
+//   DI  dst pointer, advanced by 16 bytes per quad
+//   SI  ctrl base      CX  ctrl length
+//   DX  data base      R8  data length
+//   R9  masks base     R10 lookupTotal base
+//   AX  quads decoded  BX  data bytes consumed
+
+// func decodeSSSE3(dst *uint32, ctrl, data []byte, masks *[256][16]byte) (quads, n int)
+TEXT ·decodeSSSE3(SB), NOSPLIT, $0-80
+	MOVQ dst+0(FP), DI
+	MOVQ ctrl_base+8(FP), SI
+	MOVQ ctrl_len+16(FP), CX
+	MOVQ data_base+32(FP), DX
+	MOVQ data_len+40(FP), R8
+	MOVQ masks+56(FP), R9
+	LEAQ ·lookupTotal(SB), R10
+	XORQ AX, AX
+	XORQ BX, BX
+
+	// Only loop while a whole 16-byte load stays inside of data.
+	SUBQ $16, R8
+
+ssse3Loop:
+	CMPQ AX, CX
+	JGE  ssse3Done
+	CMPQ BX, R8
+	JGT  ssse3Done
+
+	MOVBQZX (SI)(AX*1), R11
+	MOVQ    R11, R12
+	SHLQ    $4, R12
+	MOVOU   (DX)(BX*1), X0
+	MOVOU   (R9)(R12*1), X1
+	PSHUFB  X1, X0
+	MOVOU   X0, (DI)
+
+	MOVBQZX (R10)(R11*1), R12
+	ADDQ    R12, BX
+	ADDQ    $16, DI
+	INCQ    AX
+	JMP     ssse3Loop
+
+ssse3Done:
+	MOVQ AX, quads+64(FP)
+	MOVQ BX, n+72(FP)
+	RET
+
+// func decodeAVX2(dst *uint32, ctrl, data []byte, masks *[256][16]byte) (quads, n int)
+//
+// Two quads are decoded per iteration, with one in each 128-bit lane, since
+// VPSHUFB can't move bytes across lanes. The second block starts wherever
+// the first one ends, so a 32-byte margin covers both of the loads.
+TEXT ·decodeAVX2(SB), NOSPLIT, $0-80
+	MOVQ dst+0(FP), DI
+	MOVQ ctrl_base+8(FP), SI
+	MOVQ ctrl_len+16(FP), CX
+	MOVQ data_base+32(FP), DX
+	MOVQ data_len+40(FP), R8
+	MOVQ masks+56(FP), R9
+	LEAQ ·lookupTotal(SB), R10
+	XORQ AX, AX
+	XORQ BX, BX
+
+	SUBQ $1, CX
+	SUBQ $32, R8
+
+avx2Loop:
+	CMPQ AX, CX
+	JGE  avx2Tail
+	CMPQ BX, R8
+	JGT  avx2Tail
+
+	MOVBQZX (SI)(AX*1), R11
+	MOVBQZX 1(SI)(AX*1), R13
+	MOVQ    R11, R12
+	SHLQ    $4, R12
+	MOVQ    R13, R14
+	SHLQ    $4, R14
+
+	MOVBQZX (R10)(R11*1), R11
+	ADDQ    BX, R11
+
+	VMOVDQU     (DX)(BX*1), X0
+	VINSERTI128 $1, (DX)(R11*1), Y0, Y0
+	VMOVDQU     (R9)(R12*1), X1
+	VINSERTI128 $1, (R9)(R14*1), Y1, Y1
+	VPSHUFB     Y1, Y0, Y0
+	VMOVDQU     Y0, (DI)
+
+	MOVBQZX (R10)(R13*1), R13
+	LEAQ    (R11)(R13*1), BX
+	ADDQ    $32, DI
+	ADDQ    $2, AX
+	JMP     avx2Loop
+
+avx2Tail:
+	VZEROUPPER
+
+	// Finish off with single quads, as in decodeSSSE3.
+	ADDQ $1, CX
+	ADDQ $16, R8
+
+avx2TailLoop:
+	CMPQ AX, CX
+	JGE  avx2Done
+	CMPQ BX, R8
+	JGT  avx2Done
+
+	MOVBQZX (SI)(AX*1), R11
+	MOVQ    R11, R12
+	SHLQ    $4, R12
+	MOVOU   (DX)(BX*1), X0
+	MOVOU   (R9)(R12*1), X1
+	PSHUFB  X1, X0
+	MOVOU   X0, (DI)
+
+	MOVBQZX (R10)(R11*1), R12
+	ADDQ    R12, BX
+	ADDQ    $16, DI
+	INCQ    AX
+	JMP     avx2TailLoop
+
+avx2Done:
+	MOVQ AX, quads+64(FP)
+	MOVQ BX, n+72(FP)
+	RET
@@ -0,0 +1,125 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !purego
+
+package svb
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// kernels lists the decoding kernels this CPU can run, as the settings of
+// useSSSE3 and useAVX2 that select them.
+func kernels() map[string][2]bool {
+	k := map[string][2]bool{"go": {false, false}}
+	if hasSSSE3 {
+		k["ssse3"] = [2]bool{true, false}
+	}
+	if hasSSSE3 && hasAVX2 {
+		k["avx2"] = [2]bool{true, true}
+	}
+	return k
+}
+
+func TestKernelSelection(t *testing.T) {
+	// The kernel choices are made from the CPU features at start up, so
+	// they have to agree (tests that change them put them back).
+	if useSSSE3 != hasSSSE3 || useAVX2 != (hasAVX2 && hasSSSE3) || useSSE41 != (hasSSE41 && hasSSSE3) {
+		t.Errorf("kernels %t %t %t don't match CPU %t %t %t\n",
+			useSSSE3, useAVX2, useSSE41, hasSSSE3, hasAVX2, hasSSE41)
+	}
+}
+
+func TestDecodeKernels(t *testing.T) {
+	defer func(ssse3, avx2 bool) {
+		useSSSE3, useAVX2 = ssse3, avx2
+	}(useSSSE3, useAVX2)
+
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for _, f := range []Format{Legacy, Reference} {
+		for count := 0; count < 200; count++ {
+			src := make([]uint32, count)
+			for ix := range src {
+				src[ix] = r.Uint32() >> uint(8*r.Intn(4))
+			}
+			encoded := f.Encode(nil, src)
+
+			useSSSE3, useAVX2 = false, false
+			expected, err := f.Decode(nil, encoded, count)
+			if err != nil {
+				t.Fatalf("unexpected: %v\n", err)
+			}
+
+			for name, k := range kernels() {
+				useSSSE3, useAVX2 = k[0], k[1]
+				vals, err := f.Decode(nil, encoded, count)
+				if err != nil {
+					t.Errorf("%v %s: unexpected: %v\n", f, name, err)
+					continue
+				}
+				for ix := range expected {
+					if vals[ix] != expected[ix] || vals[ix] != src[ix] {
+						t.Errorf("%v %s: %d: %d != %d\n", f, name, ix, vals[ix], expected[ix])
+						break
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestShuffleMasks(t *testing.T) {
+	// 01 | 00 | 00 | 11 puts the values at offsets 0, 2, 3 and 4.
+	expected := [16]byte{
+		0x01, 0x00, 0x80, 0x80,
+		0x02, 0x80, 0x80, 0x80,
+		0x03, 0x80, 0x80, 0x80,
+		0x07, 0x06, 0x05, 0x04,
+	}
+	if shuffleMasks[0x43] != expected {
+		t.Errorf("% x != % x\n", shuffleMasks[0x43], expected)
+	}
+
+	// 11 | 00 | 00 | 01 is the same quad in the Reference format.
+	expected = [16]byte{
+		0x00, 0x01, 0x80, 0x80,
+		0x02, 0x80, 0x80, 0x80,
+		0x03, 0x80, 0x80, 0x80,
+		0x04, 0x05, 0x06, 0x07,
+	}
+	if refShuffleMasks[0xc1] != expected {
+		t.Errorf("% x != % x\n", refShuffleMasks[0xc1], expected)
+	}
+}
+
+func BenchmarkDecodeKernels(b *testing.B) {
+	defer func(ssse3, avx2 bool) {
+		useSSSE3, useAVX2 = ssse3, avx2
+	}(useSSSE3, useAVX2)
+
+	src, encoded := benchmarkStream(Reference, 4096)
+	dst := make([]uint32, len(src))
+	for name, k := range kernels() {
+		b.Run(name, func(b *testing.B) {
+			useSSSE3, useAVX2 = k[0], k[1]
+			b.SetBytes(int64(4 * len(src)))
+			for i := 0; i < b.N; i++ {
+				Reference.Decode(dst, encoded, len(src))
+			}
+		})
+	}
+}
@@ -16,6 +16,9 @@ package svb
 
 import (
 	"bytes"
+	"errors"
+	"io"
+	"math"
 	"math/rand"
 	"testing"
 	"time"
@@ -211,3 +214,258 @@ func TestU32BlockRoundtrip(t *testing.T) {
 		// t.Logf("size: %d\n", size)
 	}
 }
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		src   []byte
+		count int
+		vals  []uint32
+		err   error
+	}{
+		{
+			[]byte{},
+			0,
+			[]uint32{},
+			nil,
+		},
+		{
+			[]byte{0x01, 0x80, 0x01, 0x02, 0x03, 0x04, 0x00, 0x01, 0x00, 0x00, 0x07},
+			6,
+			[]uint32{1, 2, 3, 1024, 65536, 7},
+			nil,
+		},
+		{ // Missing the second control byte
+			[]byte{0x00},
+			5,
+			nil,
+			ErrShortControl,
+		},
+		{ // Missing the data byte for the final value
+			[]byte{0x01, 0x80, 0x01, 0x02, 0x03, 0x04, 0x00, 0x01, 0x00, 0x00},
+			6,
+			nil,
+			ErrShortData,
+		},
+		{
+			[]byte{},
+			-1,
+			nil,
+			ErrInvalidCount,
+		},
+	}
+
+	for _, test := range tests {
+		vals, err := Decode(nil, test.src, test.count)
+		if !errors.Is(err, test.err) || (err == nil) != (test.err == nil) {
+			t.Errorf("% x: %v != %v\n", test.src, err, test.err)
+			continue
+		}
+		if len(vals) != len(test.vals) {
+			t.Errorf("% x: len %d != %d\n", test.src, len(vals), len(test.vals))
+			continue
+		}
+		for ix := range vals {
+			if vals[ix] != test.vals[ix] {
+				t.Errorf("% x: %d != %d\n", test.src, vals[ix], test.vals[ix])
+			}
+		}
+	}
+}
+
+func TestEncodeDecodeRoundtrip(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for count := 0; count < 50; count++ {
+		src := make([]uint32, count)
+		for ix := range src {
+			src[ix] = r.Uint32() >> uint(8*r.Intn(4))
+		}
+
+		encoded := Encode(nil, src)
+		vals, err := Decode(nil, encoded, count)
+		if err != nil {
+			t.Errorf("unexpected: %v\n", err)
+			continue
+		}
+		for ix := range src {
+			if vals[ix] != src[ix] {
+				t.Errorf("mismatch: %v != %v\n", vals, src)
+				break
+			}
+		}
+	}
+}
+
+// benchmarkStream returns count values with a mix of byte lengths, along
+// with their encoded form, for the decode benchmarks.
+func benchmarkStream(f Format, count int) ([]uint32, []byte) {
+	r := rand.New(rand.NewSource(1))
+	src := make([]uint32, count)
+	for ix := range src {
+		src[ix] = r.Uint32() >> uint(8*r.Intn(4))
+	}
+	return src, f.Encode(nil, src)
+}
+
+func BenchmarkGetU32Block(b *testing.B) {
+	src, encoded := benchmarkStream(Legacy, 4096)
+	ctrl, data := encoded[:len(src)/4], encoded[len(src)/4:]
+	dst := make([]uint32, len(src))
+	b.SetBytes(int64(4 * len(src)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var n int
+		for ix, c := range ctrl {
+			quad, size := GetU32Block(c, data[n:], false)
+			copy(dst[4*ix:], quad[:])
+			n += size
+		}
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	for _, f := range []Format{Legacy, Reference} {
+		b.Run(f.String(), func(b *testing.B) {
+			src, encoded := benchmarkStream(f, 4096)
+			dst := make([]uint32, len(src))
+			b.SetBytes(int64(4 * len(src)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				f.Decode(dst, encoded, len(src))
+			}
+		})
+	}
+}
+
+func TestDecodeErrorOffsets(t *testing.T) {
+	tests := []struct {
+		src    []byte
+		count  int
+		err    error
+		index  int
+		offset int
+	}{
+		{ // Only 1 of the 2 control bytes
+			[]byte{0x00},
+			5,
+			ErrShortControl,
+			4,
+			1,
+		},
+		{ // The 1024 (at offset 5) is missing its second byte
+			[]byte{0x01, 0x80, 0x01, 0x02, 0x03, 0x04},
+			6,
+			ErrShortData,
+			3,
+			5,
+		},
+		{ // The 65536 (at offset 7) is entirely missing
+			[]byte{0x01, 0x80, 0x01, 0x02, 0x03, 0x04, 0x00},
+			6,
+			ErrShortData,
+			4,
+			7,
+		},
+	}
+
+	for _, test := range tests {
+		_, err := Decode(nil, test.src, test.count)
+		derr, ok := err.(*DecodeError)
+		if !ok {
+			t.Errorf("% x: not a *DecodeError: %v\n", test.src, err)
+			continue
+		}
+		if derr.Err != test.err || derr.Index != test.index || derr.Offset != test.offset {
+			t.Errorf("% x: %v != %v (value %d, offset %d)\n",
+				test.src, derr, test.err, test.index, test.offset)
+		}
+	}
+}
+
+func TestDecodeNeverPanics(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for ix := 0; ix < 1000; ix++ {
+		src := make([]byte, r.Intn(64))
+		r.Read(src)
+		count := r.Intn(4 * (len(src) + 1))
+		for _, f := range []Format{Legacy, Reference} {
+			f.Decode(nil, src, count)
+			f.DecodeDelta(nil, src, count, 0)
+			f.Decode0124(nil, src, count)
+		}
+		Decode64(nil, src, count)
+	}
+}
+
+func TestDecodeHugeCount(t *testing.T) {
+	src := []byte{0x00, 0x01, 0x02, 0x03, 0x04}
+	for _, count := range []int{math.MaxInt32, math.MaxInt64 - 3, math.MaxInt64} {
+		for _, f := range []Format{Legacy, Reference} {
+			if _, err := f.Decode(nil, src, count); !errors.Is(err, ErrShortControl) {
+				t.Errorf("%v %d: %v != %v\n", f, count, err, ErrShortControl)
+			}
+			if _, err := f.DecodeDelta(nil, src, count, 0); !errors.Is(err, ErrShortControl) {
+				t.Errorf("%v %d: %v != %v\n", f, count, err, ErrShortControl)
+			}
+			if _, err := f.DecodeInt32(nil, src, count); !errors.Is(err, ErrShortControl) {
+				t.Errorf("%v %d: %v != %v\n", f, count, err, ErrShortControl)
+			}
+			if _, err := f.Decode0124(nil, src, count); !errors.Is(err, ErrShortControl) {
+				t.Errorf("%v %d: %v != %v\n", f, count, err, ErrShortControl)
+			}
+		}
+		if _, err := Decode64(nil, src, count); !errors.Is(err, ErrShortControl) {
+			t.Errorf("%d: %v != %v\n", count, err, ErrShortControl)
+		}
+		if _, err := Decode16(nil, src, count); !errors.Is(err, ErrShortControl) {
+			t.Errorf("%d: %v != %v\n", count, err, ErrShortControl)
+		}
+	}
+}
+
+func TestGetU32BlockChecked(t *testing.T) {
+	for _, f := range []Format{Legacy, Reference} {
+		for ctrl := 0; ctrl < 256; ctrl++ {
+			size := int(lookupTotal[ctrl])
+			data := make([]byte, size)
+			for n := 0; n < size; n++ {
+				_, _, err := f.GetU32BlockChecked(byte(ctrl), data[:n], false)
+				if !errors.Is(err, ErrShortData) {
+					t.Errorf("%v %#x, %d: %v != %v\n", f, ctrl, n, err, ErrShortData)
+				}
+			}
+			if _, n, err := f.GetU32BlockChecked(byte(ctrl), data, false); err != nil || n != size {
+				t.Errorf("%v %#x: %d, %v\n", f, ctrl, n, err)
+			}
+		}
+	}
+}
+
+func TestDecodeUnchecked(t *testing.T) {
+	src := []uint32{1, 2, 3, 1024, 65536, 7}
+	vals := DecodeUnchecked(nil, Encode(nil, src), len(src))
+	for ix := range src {
+		if vals[ix] != src[ix] {
+			t.Errorf("mismatch: %v != %v\n", vals, src)
+			break
+		}
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("no panic received")
+		}
+	}()
+	DecodeUnchecked(nil, []byte{0xff, 0x00}, 4)
+}
+
+func TestReadUint32sUnexpectedEOF(t *testing.T) {
+	// The control byte calls for 8 bytes, but only 5 are there.
+	_, err := ReadUint32s(0x43, bytes.NewBuffer([]byte{0x04, 0x00, 0x0c, 0x0a, 0x40}))
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("%v != %v\n", err, io.ErrUnexpectedEOF)
+	}
+	_, err = ReadUint32s(0x43, bytes.NewBuffer(nil))
+	if err != io.EOF {
+		t.Errorf("%v != %v\n", err, io.EOF)
+	}
+}
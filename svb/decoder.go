@@ -0,0 +1,242 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrSeekRange is returned by Decoder.Seek for a position outside of the
+// stream.
+var ErrSeekRange = errors.New("svb: seek position out of range")
+
+// Decoder decodes a stream held in memory, in the layout produced by Encode
+// or EncodeDelta, a few values at a time. With a skip index, it can Seek to
+// any position without decoding (or for plain streams, even looking at) the
+// bulk of what comes before it.
+type Decoder struct {
+	format Format
+	ctrl   []byte
+	data   []byte
+	count  int
+	delta  bool
+	first  uint32
+	index  *Index
+
+	// The next quad to decode, where its data starts, and (for delta
+	// streams) the value from just before it.
+	quad int
+	off  int
+	prev uint32
+
+	// The values of the last quad decoded that haven't been read yet.
+	buf    [4]uint32
+	bufPos int
+	bufLen int
+}
+
+// NewDecoder returns a Decoder for the count values that Encode stored in
+// src. The index may be nil.
+func NewDecoder(src []byte, count int, index *Index) (*Decoder, error) {
+	return Legacy.NewDecoder(src, count, index)
+}
+
+// NewDecoder is the Format-specific version of the package-level
+// NewDecoder.
+func (f Format) NewDecoder(src []byte, count int, index *Index) (*Decoder, error) {
+	return f.newDecoder(src, count, false, 0, index)
+}
+
+// NewDeltaDecoder returns a Decoder for the count values that EncodeDelta
+// stored in src, where prev is the value that was given to EncodeDelta. The
+// index may be nil.
+func NewDeltaDecoder(src []byte, count int, prev uint32, index *Index) (*Decoder, error) {
+	return Legacy.NewDeltaDecoder(src, count, prev, index)
+}
+
+// NewDeltaDecoder is the Format-specific version of the package-level
+// NewDeltaDecoder.
+func (f Format) NewDeltaDecoder(src []byte, count int, prev uint32, index *Index) (*Decoder, error) {
+	return f.newDecoder(src, count, true, prev, index)
+}
+
+func (f Format) newDecoder(src []byte, count int, delta bool, prev uint32, index *Index) (*Decoder, error) {
+	if count < 0 {
+		return nil, ErrInvalidCount
+	}
+	if count > 4*len(src) {
+		return nil, &DecodeError{Err: ErrShortControl, Index: 4 * len(src), Offset: len(src)}
+	}
+	clen := (count + 3) / 4
+	if index != nil {
+		if err := index.check(clen, len(src)-clen, delta); err != nil {
+			return nil, err
+		}
+	}
+	return &Decoder{
+		format: f,
+		ctrl:   src[:clen],
+		data:   src[clen:],
+		count:  count,
+		delta:  delta,
+		first:  prev,
+		index:  index,
+		prev:   prev,
+	}, nil
+}
+
+// Len returns the number of values in the stream.
+func (d *Decoder) Len() int {
+	return d.count
+}
+
+// Pos returns the position of the next value to be read.
+func (d *Decoder) Pos() int {
+	end := 4 * d.quad
+	if end > d.count {
+		end = d.count
+	}
+	return end - (d.bufLen - d.bufPos)
+}
+
+// Seek moves to position i, so that the next value read is the i-th one.
+// Seeking to Len() leaves nothing more to read.
+func (d *Decoder) Seek(i int) error {
+	if i < 0 || i > d.count {
+		return ErrSeekRange
+	}
+	q := i / 4
+	d.quad, d.off, d.prev = 0, 0, d.first
+	d.bufPos, d.bufLen = 0, 0
+	if d.index != nil && q > 0 {
+		k := d.index.entry(q)
+		d.quad, d.off = k*d.index.Interval, d.index.Offsets[k]
+		if d.delta {
+			d.prev = d.index.Bases[k]
+		}
+	}
+
+	// Plain streams can skip straight to the quad, but delta streams need
+	// to decode their way there to keep the running value.
+	if !d.delta {
+		d.off += skipData(d.ctrl[d.quad:q])
+		d.quad = q
+	}
+	for d.quad < q {
+		if err := d.fill(); err != nil {
+			return err
+		}
+	}
+	d.bufPos, d.bufLen = 0, 0
+	if i%4 != 0 {
+		if err := d.fill(); err != nil {
+			return err
+		}
+		d.bufPos = i % 4
+	}
+	return nil
+}
+
+// Read decodes up to len(dst) values into dst, returning the number of
+// values decoded. At the end of the stream it returns 0 and io.EOF.
+func (d *Decoder) Read(dst []uint32) (int, error) {
+	var n int
+	for n < len(dst) {
+		if d.bufPos < d.bufLen {
+			c := copy(dst[n:], d.buf[d.bufPos:d.bufLen])
+			d.bufPos += c
+			n += c
+			continue
+		}
+		if 4*d.quad >= d.count {
+			break
+		}
+
+		// As many whole quads as fit go straight into dst.
+		full := (len(dst) - n) / 4
+		if left := d.count/4 - d.quad; full > left {
+			full = left
+		}
+		if full == 0 {
+			if err := d.fill(); err != nil {
+				return n, err
+			}
+			continue
+		}
+		ctrl := d.ctrl[d.quad : d.quad+full]
+		if d.off+skipData(ctrl) > len(d.data) {
+			return n, d.shortData()
+		}
+		out := dst[n : n+4*full]
+		d.off += d.format.decodeQuads(out, ctrl, d.data[d.off:])
+		if d.delta {
+			for ix := range out {
+				d.prev += out[ix]
+				out[ix] = d.prev
+			}
+		}
+		d.quad += full
+		n += 4 * full
+	}
+	if n == 0 && len(dst) > 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// ReadUint32 decodes a single value. At the end of the stream it returns
+// io.EOF.
+func (d *Decoder) ReadUint32() (uint32, error) {
+	var v [1]uint32
+	if _, err := d.Read(v[:]); err != nil {
+		return 0, err
+	}
+	return v[0], nil
+}
+
+// fill decodes the next quad into buf.
+func (d *Decoder) fill() error {
+	c := d.ctrl[d.quad]
+	k := d.count - 4*d.quad
+	if k > 4 {
+		k = 4
+	}
+	blens := d.format.table()[c]
+	var need int
+	for _, blen := range blens[:k] {
+		need += int(blen)
+	}
+	if d.off+need > len(d.data) {
+		return d.shortData()
+	}
+
+	quad := d.format.getPartial(c, d.data[d.off:d.off+need])
+	if d.delta {
+		for ix := range quad[:k] {
+			d.prev += quad[ix]
+			quad[ix] = d.prev
+		}
+	}
+	d.buf, d.bufPos, d.bufLen = quad, 0, k
+	d.off += need
+	d.quad++
+	return nil
+}
+
+// shortData builds the error for running out of data at the current quad.
+func (d *Decoder) shortData() error {
+	return &DecodeError{Err: ErrShortData, Index: 4 * d.quad, Offset: len(d.ctrl) + d.off}
+}
@@ -0,0 +1,136 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import (
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDecoderSeek(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	src := make([]uint32, 131)
+	var sum uint32
+	for ix := range src {
+		sum += r.Uint32() >> uint(8*r.Intn(4)+4)
+		src[ix] = sum
+	}
+
+	for _, f := range []Format{Legacy, Reference} {
+		for _, delta := range []bool{false, true} {
+			for _, interval := range []int{-1, 1, 5} {
+				var d *Decoder
+				var err error
+				switch {
+				case delta && interval < 0:
+					d, err = f.NewDeltaDecoder(f.EncodeDelta(nil, src, 7), len(src), 7, nil)
+				case delta:
+					encoded, idx := f.EncodeDeltaIndexed(nil, src, 7, interval)
+					d, err = f.NewDeltaDecoder(encoded, len(src), 7, idx)
+				case interval < 0:
+					d, err = f.NewDecoder(f.Encode(nil, src), len(src), nil)
+				default:
+					encoded, idx := f.EncodeIndexed(nil, src, interval)
+					d, err = f.NewDecoder(encoded, len(src), idx)
+				}
+				if err != nil {
+					t.Fatalf("%v %t %d: %v\n", f, delta, interval, err)
+				}
+
+				for i := 0; i <= len(src); i++ {
+					if err := d.Seek(i); err != nil {
+						t.Errorf("%v %t %d %d: %v\n", f, delta, interval, i, err)
+						continue
+					}
+					if d.Pos() != i {
+						t.Errorf("%v %t %d: pos %d != %d\n", f, delta, interval, d.Pos(), i)
+					}
+					// Read in odd sizes to mix whole quads with partial ones.
+					out := make([]uint32, 0, len(src)-i)
+					buf := make([]uint32, 1+i%11)
+					for {
+						n, err := d.Read(buf)
+						out = append(out, buf[:n]...)
+						if err == io.EOF {
+							break
+						}
+						if err != nil {
+							t.Fatalf("%v %t %d %d: %v\n", f, delta, interval, i, err)
+						}
+					}
+					if !reflect.DeepEqual(out, src[i:]) {
+						t.Errorf("%v %t %d %d: %v != %v\n", f, delta, interval, i, out, src[i:])
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestDecoderReadUint32(t *testing.T) {
+	src := []uint32{1, 1 << 8, 1 << 16, 1 << 24, 7}
+	d, err := NewDecoder(Encode(nil, src), len(src), nil)
+	if err != nil {
+		t.Fatalf("%v\n", err)
+	}
+	for ix, expected := range src {
+		if num, err := d.ReadUint32(); err != nil || num != expected {
+			t.Errorf("%d: %d, %v != %d\n", ix, num, err, expected)
+		}
+	}
+	if _, err := d.ReadUint32(); err != io.EOF {
+		t.Errorf("%v != %v\n", err, io.EOF)
+	}
+	if err := d.Seek(len(src) + 1); err != ErrSeekRange {
+		t.Errorf("%v != %v\n", err, ErrSeekRange)
+	}
+}
+
+func TestDecoderShortData(t *testing.T) {
+	src := make([]uint32, 37)
+	for ix := range src {
+		src[ix] = 1 << 24
+	}
+	encoded := Encode(nil, src)
+	for _, size := range []int{0, 1, 2, 5, 16, 37} {
+		d, err := NewDecoder(encoded[:len(encoded)-size], len(src), nil)
+		if err != nil {
+			t.Fatalf("%d: %v\n", size, err)
+		}
+		out := make([]uint32, size%3+1)
+		for err == nil {
+			_, err = d.Read(out)
+		}
+		var de *DecodeError
+		if size == 0 {
+			if err != io.EOF {
+				t.Errorf("%d: %v\n", size, err)
+			}
+		} else if !errors.As(err, &de) || de.Err != ErrShortData {
+			t.Errorf("%d: %v\n", size, err)
+		}
+	}
+
+	for _, count := range []int{5, math.MaxInt64 - 3, math.MaxInt64} {
+		if _, err := NewDecoder([]byte{0x00}, count, nil); !errors.Is(err, ErrShortControl) {
+			t.Errorf("%d: %v\n", count, err)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+// EncodeDelta is like Encode, but uses "differential coding" that carries
+// across the whole stream: each value is stored as its difference from the
+// value before it, and prev stands in for the value before src[0]. (This is
+// unlike the diff option of PutU32Block, which starts over from zero for
+// every quad.) With the Reference format, it writes the same layout as
+// streamvbyte_delta_encode from the reference C implementation (see
+// referenceDeltaVectors in the tests).
+//
+// The values are expected to be in ascending sorted order, and no smaller
+// than prev. Anything else still round-trips, since the differences wrap
+// around, but it will compress poorly.
+func EncodeDelta(dst []byte, src []uint32, prev uint32) []byte {
+	return Legacy.EncodeDelta(dst, src, prev)
+}
+
+// EncodeDelta is the Format-specific version of the package-level
+// EncodeDelta.
+func (f Format) EncodeDelta(dst []byte, src []uint32, prev uint32) []byte {
+	return f.encode(dst, src, true, prev)
+}
+
+// DecodeDelta is the read-side parallel to EncodeDelta. The prev value must
+// be the same one that was given to EncodeDelta.
+func DecodeDelta(dst []uint32, src []byte, count int, prev uint32) ([]uint32, error) {
+	return Legacy.DecodeDelta(dst, src, count, prev)
+}
+
+// DecodeDelta is the Format-specific version of the package-level
+// DecodeDelta.
+func (f Format) DecodeDelta(dst []uint32, src []byte, count int, prev uint32) ([]uint32, error) {
+	return f.decode(dst, src, count, true, prev)
+}
@@ -0,0 +1,100 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestEncodeDelta(t *testing.T) {
+	tests := []struct {
+		format Format
+		input  []uint32
+		prev   uint32
+		data   []byte
+	}{
+		{ // The differences carry over into the second quad
+			Legacy,
+			[]uint32{1000, 1001, 1002, 1003, 1004, 1260},
+			1000,
+			[]byte{
+				0x00, 0x10, // controls
+				0x00, 0x01, 0x01, 0x01, // 0, 1, 1, 1
+				0x01, 0x01, 0x00, // 1, 256
+			},
+		},
+		{ // Same as above, in the reference layout
+			Reference,
+			[]uint32{1000, 1001, 1002, 1003, 1004, 1260},
+			1000,
+			[]byte{
+				0x00, 0x04, // controls
+				0x00, 0x01, 0x01, 0x01, // 0, 1, 1, 1
+				0x01, 0x00, 0x01, // 1, 256
+			},
+		},
+	}
+
+	for _, test := range tests {
+		out := test.format.EncodeDelta(nil, test.input, test.prev)
+		if !bytes.Equal(out, test.data) {
+			t.Errorf("%v: % x != % x\n", test.format, out, test.data)
+		}
+
+		vals, err := test.format.DecodeDelta(nil, out, len(test.input), test.prev)
+		if err != nil {
+			t.Errorf("%v: unexpected: %v\n", test.format, err)
+			continue
+		}
+		for ix := range test.input {
+			if vals[ix] != test.input[ix] {
+				t.Errorf("%v: %d != %d\n", test.format, vals[ix], test.input[ix])
+			}
+		}
+	}
+}
+
+func TestDeltaRoundtrip(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for count := 0; count < 50; count++ {
+		prev := r.Uint32()>>8 | 1<<20
+		src := make([]uint32, count)
+		next := prev
+		for ix := range src {
+			next += uint32(r.Intn(1 << uint(4*r.Intn(4))))
+			src[ix] = next
+		}
+
+		encoded := EncodeDelta(nil, src, prev)
+		if plain := Encode(nil, src); count > 4 && len(encoded) >= len(plain) {
+			t.Errorf("delta is no smaller: %d >= %d\n", len(encoded), len(plain))
+		}
+
+		vals, err := DecodeDelta(nil, encoded, count, prev)
+		if err != nil {
+			t.Errorf("unexpected: %v\n", err)
+			continue
+		}
+		for ix := range src {
+			if vals[ix] != src[ix] {
+				t.Errorf("mismatch: %v != %v\n", vals, src)
+				break
+			}
+		}
+	}
+}
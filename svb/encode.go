@@ -14,36 +14,26 @@
 
 package svb
 
-var offsets = []uint8{24, 16, 8, 0}
+import "math/bits"
 
 // PutUint32s encodes a quad of uint32 into the data buffer, returning
 // the control byte that signifies the encoded byte lengths, and the length
 // of how many bytes got written to the data buffer.
 // If the buffer is too small, PutUStreamVByte will panic
 func PutUint32s(data []byte, num0, num1, num2, num3 uint32) (ctrl byte, n int) {
-	for _, num := range []uint32{num0, num1, num2, num3} {
-		ctrl <<= 2
-		blen := byteLength(num)
-		ctrl |= byte(blen - 1)
-		for _, offset := range offsets[(4 - blen):] {
-			data[n] = byte((num >> offset) & 0xff)
-			n++
-		}
-	}
-	return ctrl, n
+	return Legacy.PutUint32s(data, num0, num1, num2, num3)
+}
+
+// PutUint32s is the Format-specific version of the package-level PutUint32s.
+func (f Format) PutUint32s(data []byte, num0, num1, num2, num3 uint32) (ctrl byte, n int) {
+	return f.PutU32Block(data, []uint32{num0, num1, num2, num3}, false)
 }
 
+// byteLength is the number of bytes it takes to hold n, which is at least
+// 1. Counting the bits, rather than comparing, keeps it free of branches
+// that random-sized values would mispredict.
 func byteLength(n uint32) uint8 {
-	if n < 256 {
-		return 1
-	}
-	if n < 65536 {
-		return 2
-	}
-	if n < 16777216 {
-		return 3
-	}
-	return 4
+	return uint8((bits.Len32(n|1) + 7) >> 3)
 }
 
 // PutU32Block encodes a single quad of uint32 values. (This function is the
@@ -64,6 +54,12 @@ func byteLength(n uint32) uint8 {
 // Panics will be thrown if there are too few bytes available in the data
 // buffer, or too few values in the quad buffer.
 func PutU32Block(data []byte, quad []uint32, diff bool) (ctrl byte, n int) {
+	return Legacy.PutU32Block(data, quad, diff)
+}
+
+// PutU32Block is the Format-specific version of the package-level
+// PutU32Block.
+func (f Format) PutU32Block(data []byte, quad []uint32, diff bool) (ctrl byte, n int) {
 	var prev uint32
 	for i := uint(0); i < 4; i++ {
 		num := quad[i]
@@ -72,21 +68,130 @@ func PutU32Block(data []byte, quad []uint32, diff bool) (ctrl byte, n int) {
 			prev += num
 		}
 		blen := byteLength(num)
-		ctrl |= ((blen - 1) << (6 - 2*i))
-		if blen == 4 {
-			data[n] = byte((num >> 24) & 0xff)
-			n++
+		ctrl |= ((blen - 1) << f.shift(i))
+		if f == Reference {
+			n += putLittle(data[n:], num, blen)
+		} else {
+			n += putBig(data[n:], num, blen)
 		}
-		if blen >= 3 {
-			data[n] = byte((num >> 16) & 0xff)
-			n++
+	}
+	return ctrl, n
+}
+
+// putBig writes the low blen bytes of num, most significant byte first.
+func putBig(data []byte, num uint32, blen uint8) (n int) {
+	if blen == 4 {
+		data[n] = byte((num >> 24) & 0xff)
+		n++
+	}
+	if blen >= 3 {
+		data[n] = byte((num >> 16) & 0xff)
+		n++
+	}
+	if blen >= 2 {
+		data[n] = byte((num >> 8) & 0xff)
+		n++
+	}
+	data[n] = byte(num & 0xff)
+	n++
+	return n
+}
+
+// putLittle writes the low blen bytes of num, least significant byte first.
+func putLittle(data []byte, num uint32, blen uint8) (n int) {
+	data[n] = byte(num & 0xff)
+	n++
+	if blen >= 2 {
+		data[n] = byte((num >> 8) & 0xff)
+		n++
+	}
+	if blen >= 3 {
+		data[n] = byte((num >> 16) & 0xff)
+		n++
+	}
+	if blen == 4 {
+		data[n] = byte((num >> 24) & 0xff)
+		n++
+	}
+	return n
+}
+
+// Encode returns the Stream VByte encoding of src, using the canonical
+// layout: all of the control bytes first, followed by all of the data bytes.
+// The returned slice may be a sub-slice of dst if dst was large enough to
+// hold the entire encoded stream, otherwise a newly allocated slice will be
+// returned.
+//
+// When len(src) is not a multiple of 4, the unused slots in the final
+// control byte are left as zero, and no data bytes are written for them.
+// The caller needs to keep track of len(src) in order to decode the result.
+func Encode(dst []byte, src []uint32) []byte {
+	return Legacy.Encode(dst, src)
+}
+
+// Encode is the Format-specific version of the package-level Encode.
+func (f Format) Encode(dst []byte, src []uint32) []byte {
+	return f.encode(dst, src, false, 0)
+}
+
+// encode does the work for both Encode and EncodeDelta. When delta is set,
+// each value is stored as its difference from the value before it, with
+// prev standing in for the value before src[0].
+func (f Format) encode(dst []byte, src []uint32, delta bool, prev uint32) []byte {
+	clen := (len(src) + 3) / 4
+	if max := MaxEncodedLen(len(src)); cap(dst) < max {
+		dst = make([]byte, max)
+	} else {
+		dst = dst[:max]
+	}
+	n := f.encodeTo(dst[:clen], dst[clen:], src, delta, prev)
+	return dst[:clen+n]
+}
+
+// encodeTo is encode for when the control and data sections are already
+// laid out: it fills in a control byte for each quad of src, writes the data
+// bytes to the front of data, and returns how many there were. Nothing is
+// written past len(data), so data may be cut to the exact size.
+func (f Format) encodeTo(ctrl, data []byte, src []uint32, delta bool, prev uint32) int {
+	// The vector kernel (where there is one) takes the leading quads, unless
+	// differences are needed.
+	var ix, n int
+	if !delta {
+		var quads int
+		quads, n = f.encodeQuadsSIMD(ctrl, data, src)
+		ix = 4 * quads
+	}
+
+	var quad [4]uint32
+	for ; ix < len(src); ix += 4 {
+		k := copy(quad[:], src[ix:])
+		if delta {
+			for jx := 0; jx < k; jx++ {
+				quad[jx], prev = quad[jx]-prev, quad[jx]
+			}
 		}
-		if blen >= 2 {
-			data[n] = byte((num >> 8) & 0xff)
-			n++
+		var c byte
+		var size int
+		if k == 4 {
+			c, size = f.PutU32Block(data[n:], quad[:], false)
+		} else {
+			c, size = f.putPartial(data[n:], quad[:k])
 		}
-		data[n] = byte(num & 0xff)
-		n++
+		ctrl[ix/4] = c
+		n += size
 	}
+	return n
+}
+
+// putPartial encodes the trailing 1-3 values of a stream. The missing values
+// are treated as zeros, which use the smallest length code, and their data
+// bytes (which would be the last ones written) are dropped.
+func (f Format) putPartial(data []byte, vals []uint32) (ctrl byte, n int) {
+	var quad [4]uint32
+	var scratch [16]byte
+	copy(quad[:], vals)
+	ctrl, n = f.PutU32Block(scratch[:], quad[:], false)
+	n -= 4 - len(vals)
+	copy(data, scratch[:n])
 	return ctrl, n
 }
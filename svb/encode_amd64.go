@@ -0,0 +1,82 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !purego
+
+package svb
+
+// encodeMasks and refEncodeMasks hold a PSHUFB mask for every control byte,
+// which is the inverse of the decoding masks: it packs the bytes that are
+// needed out of four uint32 lanes into the front of the block.
+var encodeMasks, refEncodeMasks [256][16]byte
+
+// encodeConsts and refEncodeConsts are the vectors the encoding kernel
+// works with. The first three rows are the thresholds for needing a 2nd,
+// 3rd and 4th byte. The last row is what each lane's 2-bit code gets
+// multiplied by, which shifts it into place within the control byte.
+var (
+	encodeConsts = [4][4]uint32{
+		{1 << 8, 1 << 8, 1 << 8, 1 << 8},
+		{1 << 16, 1 << 16, 1 << 16, 1 << 16},
+		{1 << 24, 1 << 24, 1 << 24, 1 << 24},
+		{64, 16, 4, 1},
+	}
+	refEncodeConsts = [4][4]uint32{
+		{1 << 8, 1 << 8, 1 << 8, 1 << 8},
+		{1 << 16, 1 << 16, 1 << 16, 1 << 16},
+		{1 << 24, 1 << 24, 1 << 24, 1 << 24},
+		{1, 4, 16, 64},
+	}
+)
+
+// useSSE41 picks the encoding kernel. It starts out as whatever the CPU
+// supports, and is only ever changed by tests.
+var useSSE41 = hasSSE41 && hasSSSE3
+
+func init() {
+	for ctrl := 0; ctrl < 256; ctrl++ {
+		for ix := range encodeMasks[ctrl] {
+			encodeMasks[ctrl][ix] = 0x80
+			refEncodeMasks[ctrl][ix] = 0x80
+		}
+		for ix := 0; ix < 4; ix++ {
+			blen := lookup[ctrl][ix]
+			for b := uint8(0); b < blen; b++ {
+				encodeMasks[ctrl][lookupOffset[ctrl][ix]+b] = byte(4*ix) + blen - 1 - b
+			}
+			blen = refLookup[ctrl][ix]
+			for b := uint8(0); b < blen; b++ {
+				refEncodeMasks[ctrl][refLookupOffset[ctrl][ix]+b] = byte(4*ix) + b
+			}
+		}
+	}
+}
+
+// encodeSSE41 encodes the quads of src for as long as there are whole quads
+// left and at least 16 bytes of room left in data, since each block is
+// stored as a whole vector. It returns the number of quads encoded and the
+// data bytes used, and writes one control byte per quad into ctrl.
+func encodeSSE41(ctrl, data []byte, src []uint32, masks *[256][16]byte, consts *[4][4]uint32) (quads, n int)
+
+// encodeQuadsSIMD encodes as many of the leading quads of src as the vector
+// kernel can handle, leaving the rest to the caller.
+func (f Format) encodeQuadsSIMD(ctrl, data []byte, src []uint32) (quads, n int) {
+	if !useSSE41 || len(src) < 4 {
+		return 0, 0
+	}
+	if f == Reference {
+		return encodeSSE41(ctrl, data, src, &refEncodeMasks, &refEncodeConsts)
+	}
+	return encodeSSE41(ctrl, data, src, &encodeMasks, &encodeConsts)
+}
@@ -0,0 +1,108 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !purego
+
+#include "textflag.h"
+
+// Register use:
+//   SI  ctrl base      CX  number of quads to encode
+//   DI  data base      R8  data length
+//   DX  src pointer, advanced by 16 bytes per quad
+//   R9  masks base     R10 lookupTotal base
+//   AX  quads encoded  BX  data bytes used
+//   X8, X9, X10  the 2nd, 3rd and 4th byte thresholds
+//   X11          the control byte multipliers
+
+// func encodeSSE41(ctrl, data []byte, src []uint32, masks *[256][16]byte, consts *[4][4]uint32) (quads, n int)
+TEXT ·encodeSSE41(SB), NOSPLIT, $0-104
+	MOVQ ctrl_base+0(FP), SI
+	MOVQ ctrl_len+8(FP), CX
+	MOVQ data_base+24(FP), DI
+	MOVQ data_len+32(FP), R8
+	MOVQ src_base+48(FP), DX
+	MOVQ src_len+56(FP), R11
+	MOVQ masks+72(FP), R9
+	MOVQ consts+80(FP), R12
+	LEAQ ·lookupTotal(SB), R10
+
+	MOVOU 0(R12), X8
+	MOVOU 16(R12), X9
+	MOVOU 32(R12), X10
+	MOVOU 48(R12), X11
+
+	// Don't go past the end of ctrl, or the last whole quad of src.
+	SHRQ $2, R11
+	CMPQ R11, CX
+	JGE  ready
+	MOVQ R11, CX
+
+ready:
+	XORQ AX, AX
+	XORQ BX, BX
+
+	// Only loop while a whole 16-byte store stays inside of data.
+	SUBQ $16, R8
+
+loop:
+	CMPQ AX, CX
+	JGE  done
+	CMPQ BX, R8
+	JGT  done
+
+	MOVOU (DX), X0
+
+	// Each lane is -1 for every threshold that it reaches, since x >= t
+	// exactly when min(x, t) == t. Negating the sum gives the 2-bit code.
+	MOVO    X0, X1
+	PMINUD  X8, X1
+	PCMPEQL X8, X1
+	MOVO    X0, X2
+	PMINUD  X9, X2
+	PCMPEQL X9, X2
+	MOVO    X0, X3
+	PMINUD  X10, X3
+	PCMPEQL X10, X3
+	PADDL   X2, X1
+	PADDL   X3, X1
+	PXOR    X4, X4
+	PSUBL   X1, X4
+
+	// Shift the codes into place, and add the lanes together.
+	PMULLD X11, X4
+	PSHUFD $0x4e, X4, X5
+	PADDL  X5, X4
+	PSHUFD $0xb1, X4, X5
+	PADDL  X5, X4
+	MOVQ   X4, R11
+	MOVB   R11, (SI)(AX*1)
+
+	// Pack the bytes that are needed to the front, and store them.
+	MOVBQZX R11B, R11
+	MOVQ    R11, R12
+	SHLQ    $4, R12
+	MOVOU   (R9)(R12*1), X1
+	PSHUFB  X1, X0
+	MOVOU   X0, (DI)(BX*1)
+
+	MOVBQZX (R10)(R11*1), R12
+	ADDQ    R12, BX
+	ADDQ    $16, DX
+	INCQ    AX
+	JMP     loop
+
+done:
+	MOVQ AX, quads+88(FP)
+	MOVQ BX, n+96(FP)
+	RET
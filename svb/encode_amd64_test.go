@@ -0,0 +1,90 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !purego
+
+package svb
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestEncodeKernel(t *testing.T) {
+	if !hasSSE41 || !hasSSSE3 {
+		t.Skip("CPU lacks SSE4.1/SSSE3")
+	}
+	defer func(sse41 bool) {
+		useSSE41 = sse41
+	}(useSSE41)
+
+	// Every length boundary, as well as random values.
+	edges := []uint32{0, 1, 255, 256, 65535, 65536, 16777215, 16777216, 4294967295}
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for _, f := range []Format{Legacy, Reference} {
+		for count := 0; count < 200; count++ {
+			src := make([]uint32, count)
+			for ix := range src {
+				if r.Intn(4) == 0 {
+					src[ix] = edges[r.Intn(len(edges))]
+				} else {
+					src[ix] = r.Uint32() >> uint(8*r.Intn(4))
+				}
+			}
+
+			useSSE41 = false
+			expected := f.Encode(nil, src)
+			useSSE41 = true
+			out := f.Encode(nil, src)
+			if !bytes.Equal(out, expected) {
+				t.Errorf("%v: %v\n% x !=\n% x\n", f, src, out, expected)
+			}
+		}
+	}
+}
+
+func TestEncodeMasks(t *testing.T) {
+	// 01 | 00 | 00 | 11 takes 2 bytes from the first lane, 1 from each of
+	// the next two, and all 4 from the last one.
+	expected := [16]byte{
+		0x01, 0x00,
+		0x04,
+		0x08,
+		0x0f, 0x0e, 0x0d, 0x0c,
+		0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80,
+	}
+	if encodeMasks[0x43] != expected {
+		t.Errorf("% x != % x\n", encodeMasks[0x43], expected)
+	}
+}
+
+func BenchmarkEncodeKernel(b *testing.B) {
+	defer func(sse41 bool) {
+		useSSE41 = sse41
+	}(useSSE41)
+
+	src, _ := benchmarkStream(Legacy, 4096)
+	dst := make([]byte, len(src)/4+4*len(src))
+	for name, k := range map[string]bool{"go": false, "sse41": hasSSE41 && hasSSSE3} {
+		b.Run(name, func(b *testing.B) {
+			useSSE41 = k
+			b.SetBytes(int64(4 * len(src)))
+			for i := 0; i < b.N; i++ {
+				Encode(dst, src)
+			}
+		})
+	}
+}
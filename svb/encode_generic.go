@@ -0,0 +1,23 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !amd64 || purego
+
+package svb
+
+// encodeQuadsSIMD leaves all of the quads to the pure Go encoder, on
+// platforms without an assembly kernel.
+func (f Format) encodeQuadsSIMD(ctrl, data []byte, src []uint32) (quads, n int) {
+	return 0, 0
+}
@@ -14,7 +14,10 @@
 
 package svb
 
-import "testing"
+import (
+	"bytes"
+	"testing"
+)
 
 func TestPutUint32s(t *testing.T) {
 	tests := []struct {
@@ -117,3 +120,52 @@ func TestPutU32Block(t *testing.T) {
 		// t.Logf("% x\n", data[:size])
 	}
 }
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		input []uint32
+		data  []byte
+	}{
+		{ // Nothing to encode
+			[]uint32{},
+			[]byte{},
+		},
+		{ // From whitepapaer: 1024, 12, 10, 1073741824
+			[]uint32{1024, 12, 10, 1073741824},
+			[]byte{
+				0x43,       // control
+				0x04, 0x00, // 1024
+				0x0c,                   // 12
+				0x0a,                   // 10
+				0x40, 0x00, 0x00, 0x00, // 1,073,741,824
+			},
+		},
+		{ // Trailing partial quad
+			[]uint32{1, 2, 3, 1024, 65536, 7},
+			[]byte{
+				0x01, 0x80, // controls
+				0x01,       // 1
+				0x02,       // 2
+				0x03,       // 3
+				0x04, 0x00, // 1024
+				0x01, 0x00, 0x00, // 65536
+				0x07, // 7
+			},
+		},
+	}
+
+	for _, test := range tests {
+		out := Encode(nil, test.input)
+		if !bytes.Equal(out, test.data) {
+			t.Errorf("%v: % x != % x\n", test.input, out, test.data)
+		}
+	}
+}
+
+func TestEncodeReusesDst(t *testing.T) {
+	dst := make([]byte, 64)
+	out := Encode(dst, []uint32{1, 2, 3, 4, 5})
+	if &out[0] != &dst[0] {
+		t.Errorf("dst was not reused\n")
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrShortControl is returned when there are fewer control bytes
+	// available than are needed for the requested number of values.
+	ErrShortControl = errors.New("svb: not enough control bytes")
+
+	// ErrShortData is returned when there are fewer data bytes available
+	// than the control bytes say are needed.
+	ErrShortData = errors.New("svb: not enough data bytes")
+
+	// ErrInvalidCount is returned when a negative value count is requested.
+	ErrInvalidCount = errors.New("svb: invalid value count")
+)
+
+// DecodeError is returned by the checked decoders when the input runs out
+// before all of the values have been decoded. It wraps either
+// ErrShortControl or ErrShortData, so it can be tested for with errors.Is,
+// and says where the input ran out.
+type DecodeError struct {
+	Err error
+
+	// Index is the first value that could not be decoded.
+	Index int
+
+	// Offset is where in the input the missing bytes were expected. For
+	// the slice decoders this is relative to the start of the control
+	// bytes, and for the block decoders it is relative to the data buffer.
+	Offset int
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("%v (value %d, offset %d)", e.Err, e.Index, e.Offset)
+}
+
+// Unwrap returns ErrShortControl or ErrShortData.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// shortData builds the error for a data section that is too short, by
+// walking the value lengths to find the first one that doesn't fit. The
+// data section starts at offset base, and holds dlen bytes.
+func shortData(base, dlen, count int, blen func(ix int) int) error {
+	var n int
+	for ix := 0; ix < count; ix++ {
+		if n+blen(ix) > dlen {
+			return &DecodeError{Err: ErrShortData, Index: ix, Offset: base + n}
+		}
+		n += blen(ix)
+	}
+	// Not reached, as long as the caller has seen a shortfall.
+	return &DecodeError{Err: ErrShortData, Index: count, Offset: base + n}
+}
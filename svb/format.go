@@ -0,0 +1,69 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+// Format selects the wire layout of the control and data bytes. The
+// package-level functions all use the Legacy format; the same operations are
+// available as methods on Format for callers that need to pick the layout.
+type Format uint8
+
+const (
+	// Legacy is the layout this package has always produced: the first value
+	// of a quad is described by the high bits of the control byte, and the
+	// data bytes of each value are written big-endian.
+	Legacy Format = iota
+
+	// Reference is the layout of the reference C implementation
+	// (https://github.com/lemire/streamvbyte): the first value of a quad is
+	// described by the low bits of the control byte, and the data bytes of
+	// each value are written little-endian.
+	Reference
+)
+
+// String returns the name of the format.
+func (f Format) String() string {
+	switch f {
+	case Legacy:
+		return "legacy"
+	case Reference:
+		return "reference"
+	}
+	return "unknown"
+}
+
+// table returns the control byte lookup table for the format.
+func (f Format) table() *[256][4]uint8 {
+	if f == Reference {
+		return &refLookup
+	}
+	return &lookup
+}
+
+// offsets returns the control byte offset table for the format.
+func (f Format) offsets() *[256][4]uint8 {
+	if f == Reference {
+		return &refLookupOffset
+	}
+	return &lookupOffset
+}
+
+// shift returns how far the 2-bit length code of the i-th value in a quad is
+// shifted within the control byte.
+func (f Format) shift(i uint) uint {
+	if f == Reference {
+		return 2 * i
+	}
+	return 6 - 2*i
+}
@@ -0,0 +1,195 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// The golden vectors below are in the layouts written by the reference C
+// implementation, https://github.com/lemire/streamvbyte: control bytes with
+// the first value in the low bits, then little-endian data. They are what
+// testdata/reference_vectors.c prints when built against the library (see
+// there for how), so rerunning it is how to check them.
+
+// referenceVectors are encoded by streamvbyte_encode.
+var referenceVectors = []struct {
+	input []uint32
+	data  []byte
+}{
+	{
+		[]uint32{},
+		[]byte{},
+	},
+	{ // Figure 3 from https://arxiv.org/pdf/1709.08990.pdf
+		[]uint32{1024, 12, 10, 1073741824},
+		[]byte{0xc1, 0x00, 0x04, 0x0c, 0x0a, 0x00, 0x00, 0x00, 0x40},
+	},
+	{ // Every length boundary, with a trailing partial quad
+		[]uint32{255, 256, 65535, 65536, 16777215, 16777216, 4294967295},
+		[]byte{0x94, 0x3e, 0xff, 0x00, 0x01, 0xff, 0xff, 0x00, 0x00, 0x01, 0xff, 0xff, 0xff, 0x00, 0x00, 0x00, 0x01, 0xff, 0xff, 0xff, 0xff},
+	},
+	{ // Small values only
+		[]uint32{0, 1, 2, 3, 4},
+		[]byte{0x00, 0x00, 0x00, 0x01, 0x02, 0x03, 0x04},
+	},
+}
+
+// referenceDeltaVectors are encoded by streamvbyte_delta_encode, starting
+// from prev.
+var referenceDeltaVectors = []struct {
+	input []uint32
+	prev  uint32
+	data  []byte
+}{
+	{ // Every length boundary of the differences, from a nonzero prev
+		[]uint32{100, 101, 356, 65892, 65892, 16843107, 4294967295},
+		100,
+		[]byte{0x80, 0x38, 0x00, 0x01, 0xff, 0x00, 0x00, 0x01, 0x00, 0xff, 0xff, 0xff, 0x9c, 0xfe, 0xfe, 0xfe},
+	},
+	{ // A first value below prev wraps around
+		[]uint32{5, 6},
+		1000,
+		[]byte{0x03, 0x1d, 0xfc, 0xff, 0xff, 0x01},
+	},
+}
+
+// reference0124Vectors are encoded by streamvbyte_encode_0124.
+var reference0124Vectors = []struct {
+	input []uint32
+	data  []byte
+}{
+	{ // Every length code, with a trailing partial quad
+		[]uint32{0, 1, 255, 256, 65535, 65536, 4294967295, 0, 7},
+		[]byte{0x94, 0x3e, 0x01, 0x01, 0xff, 0x00, 0x01, 0xff, 0xff, 0x00, 0x00, 0x01, 0x00, 0xff, 0xff, 0xff, 0xff, 0x07},
+	},
+	{ // Zeros only take up control bytes
+		[]uint32{0, 0, 0, 0, 0},
+		[]byte{0x00, 0x00},
+	},
+}
+
+func TestReferenceEncode(t *testing.T) {
+	for _, test := range referenceVectors {
+		out := Reference.Encode(nil, test.input)
+		if !bytes.Equal(out, test.data) {
+			t.Errorf("%v: % x != % x\n", test.input, out, test.data)
+		}
+	}
+}
+
+func TestReferenceDecode(t *testing.T) {
+	for _, test := range referenceVectors {
+		vals, err := Reference.Decode(nil, test.data, len(test.input))
+		if err != nil {
+			t.Errorf("%v: unexpected: %v\n", test.input, err)
+			continue
+		}
+		for ix := range test.input {
+			if vals[ix] != test.input[ix] {
+				t.Errorf("%v: %d != %d\n", test.input, vals[ix], test.input[ix])
+			}
+		}
+	}
+}
+
+func TestReferenceDelta(t *testing.T) {
+	for _, test := range referenceDeltaVectors {
+		out := Reference.EncodeDelta(nil, test.input, test.prev)
+		if !bytes.Equal(out, test.data) {
+			t.Errorf("%v: % x != % x\n", test.input, out, test.data)
+		}
+
+		vals, err := Reference.DecodeDelta(nil, test.data, len(test.input), test.prev)
+		if err != nil {
+			t.Errorf("%v: unexpected: %v\n", test.input, err)
+			continue
+		}
+		for ix := range test.input {
+			if vals[ix] != test.input[ix] {
+				t.Errorf("%v: %d != %d\n", test.input, vals[ix], test.input[ix])
+			}
+		}
+	}
+}
+
+func TestReference0124(t *testing.T) {
+	for _, test := range reference0124Vectors {
+		out := Reference.Encode0124(nil, test.input)
+		if !bytes.Equal(out, test.data) {
+			t.Errorf("%v: % x != % x\n", test.input, out, test.data)
+		}
+
+		vals, err := Reference.Decode0124(nil, test.data, len(test.input))
+		if err != nil {
+			t.Errorf("%v: unexpected: %v\n", test.input, err)
+			continue
+		}
+		for ix := range test.input {
+			if vals[ix] != test.input[ix] {
+				t.Errorf("%v: %d != %d\n", test.input, vals[ix], test.input[ix])
+			}
+		}
+	}
+}
+
+func TestReferenceQuad(t *testing.T) {
+	ctrl := byte(0xc1)
+	data := []byte{0x00, 0x04, 0x0c, 0x0a, 0x00, 0x00, 0x00, 0x40}
+	expected := [4]uint32{1024, 12, 10, 1073741824}
+
+	buf := make([]byte, 16)
+	c, n := Reference.PutUint32s(buf, 1024, 12, 10, 1073741824)
+	if c != ctrl || !bytes.Equal(buf[:n], data) {
+		t.Errorf("PutUint32s: %#x % x != %#x % x\n", c, buf[:n], ctrl, data)
+	}
+
+	nums, n := Reference.Uint32s(ctrl, data)
+	if nums != expected || n != len(data) {
+		t.Errorf("Uint32s: %v, %d != %v, %d\n", nums, n, expected, len(data))
+	}
+
+	nums, err := Reference.ReadUint32s(ctrl, bytes.NewBuffer(data))
+	if err != nil || nums != expected {
+		t.Errorf("ReadUint32s: %v, %v != %v\n", nums, err, expected)
+	}
+}
+
+func TestFormatRoundtrip(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for _, f := range []Format{Legacy, Reference} {
+		for count := 0; count < 50; count++ {
+			src := make([]uint32, count)
+			for ix := range src {
+				src[ix] = r.Uint32() >> uint(8*r.Intn(4))
+			}
+
+			vals, err := f.Decode(nil, f.Encode(nil, src), count)
+			if err != nil {
+				t.Errorf("%v: unexpected: %v\n", f, err)
+				continue
+			}
+			for ix := range src {
+				if vals[ix] != src[ix] {
+					t.Errorf("%v: mismatch: %v != %v\n", f, vals, src)
+					break
+				}
+			}
+		}
+	}
+}
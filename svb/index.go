@@ -0,0 +1,213 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// ErrCorruptIndex is returned when a skip index can't be unmarshaled, or
+// doesn't fit the stream it is used with.
+var ErrCorruptIndex = errors.New("svb: corrupt skip index")
+
+// Index is a skip index (or block directory) for an encoded stream. It has
+// an entry for every Interval quads, which records where in the data
+// section that quad starts, so a Decoder can seek without having to walk
+// all of the control bytes before it.
+//
+// For a stream made by EncodeDelta, the values depend on every difference
+// that came before them, so each entry also records the running value from
+// just before its first quad. (For sorted data, this is the largest value
+// of all the earlier quads.)
+//
+// The index is not part of the encoded stream, so the stream's layout stays
+// the same whether or not it has one, and the containers made by Marshal
+// don't carry one either. To keep an index with its stream, store the bytes
+// from MarshalBinary next to it (for example, as a uvarint length and the
+// index bytes, followed by the stream), and rebuild it with UnmarshalBinary
+// before handing it to NewDecoder, NewDeltaDecoder or NewCursor. A Decoder
+// checks that the index fits its stream.
+type Index struct {
+	// Interval is the number of quads between entries.
+	Interval int
+
+	// Offsets holds, for each entry, the offset into the data section of
+	// the entry's first quad.
+	Offsets []int
+
+	// Bases holds, for each entry, the value from just before the entry's
+	// first quad. It is nil when the stream isn't delta encoded.
+	Bases []uint32
+}
+
+// EncodeIndexed is like Encode, but it also builds a skip index with an
+// entry for every interval quads.
+func EncodeIndexed(dst []byte, src []uint32, interval int) ([]byte, *Index) {
+	return Legacy.EncodeIndexed(dst, src, interval)
+}
+
+// EncodeIndexed is the Format-specific version of the package-level
+// EncodeIndexed.
+func (f Format) EncodeIndexed(dst []byte, src []uint32, interval int) ([]byte, *Index) {
+	out := f.encode(dst, src, false, 0)
+	return out, newIndex(out[:(len(src)+3)/4], src, interval, false, 0)
+}
+
+// EncodeDeltaIndexed is like EncodeDelta, but it also builds a skip index
+// with an entry for every interval quads.
+func EncodeDeltaIndexed(dst []byte, src []uint32, prev uint32, interval int) ([]byte, *Index) {
+	return Legacy.EncodeDeltaIndexed(dst, src, prev, interval)
+}
+
+// EncodeDeltaIndexed is the Format-specific version of the package-level
+// EncodeDeltaIndexed.
+func (f Format) EncodeDeltaIndexed(dst []byte, src []uint32, prev uint32, interval int) ([]byte, *Index) {
+	out := f.encode(dst, src, true, prev)
+	return out, newIndex(out[:(len(src)+3)/4], src, interval, true, prev)
+}
+
+// newIndex builds the skip index for the stream with the control bytes in
+// ctrl, which holds the values in src.
+func newIndex(ctrl []byte, src []uint32, interval int, delta bool, prev uint32) *Index {
+	if interval < 1 {
+		interval = 1
+	}
+	entries := (len(ctrl) + interval - 1) / interval
+	idx := &Index{Interval: interval, Offsets: make([]int, entries)}
+	if delta {
+		idx.Bases = make([]uint32, entries)
+	}
+
+	var off int
+	for k := 0; k < entries; k++ {
+		q := k * interval
+		if k > 0 {
+			off += skipData(ctrl[q-interval : q])
+			prev = src[4*q-1]
+		}
+		idx.Offsets[k] = off
+		if delta {
+			idx.Bases[k] = prev
+		}
+	}
+	return idx
+}
+
+// entry returns the entry to start from when seeking to the given quad.
+func (idx *Index) entry(quad int) int {
+	k := quad / idx.Interval
+	if k >= len(idx.Offsets) {
+		k = len(idx.Offsets) - 1
+	}
+	return k
+}
+
+// check makes sure that the index fits a stream of the given number of
+// quads, with dlen data bytes.
+func (idx *Index) check(quads, dlen int, delta bool) error {
+	if idx.Interval < 1 || len(idx.Offsets) != (quads+idx.Interval-1)/idx.Interval {
+		return ErrCorruptIndex
+	}
+	if delta && len(idx.Bases) != len(idx.Offsets) {
+		return ErrCorruptIndex
+	}
+	for k, off := range idx.Offsets {
+		if off < 0 || off > dlen || (k > 0 && off < idx.Offsets[k-1]) {
+			return ErrCorruptIndex
+		}
+	}
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler, so that the index can
+// be stored alongside its stream (see Index). The offsets and bases are stored as
+// uvarint differences from the entry before.
+func (idx *Index) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 0, 3*binary.MaxVarintLen64+len(idx.Offsets)*(2+binary.MaxVarintLen32))
+	var tmp [binary.MaxVarintLen64]byte
+	put := func(v uint64) {
+		buf = append(buf, tmp[:binary.PutUvarint(tmp[:], v)]...)
+	}
+
+	put(uint64(idx.Interval))
+	put(uint64(len(idx.Offsets)))
+	if idx.Bases != nil {
+		put(1)
+	} else {
+		put(0)
+	}
+	var prev int
+	for _, off := range idx.Offsets {
+		put(uint64(off - prev))
+		prev = off
+	}
+	var base uint32
+	for _, b := range idx.Bases {
+		put(uint64(b - base))
+		base = b
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (idx *Index) UnmarshalBinary(data []byte) error {
+	get := func() (uint64, bool) {
+		v, n := binary.Uvarint(data)
+		if n <= 0 {
+			return 0, false
+		}
+		data = data[n:]
+		return v, true
+	}
+
+	interval, ok1 := get()
+	entries, ok2 := get()
+	hasBases, ok3 := get()
+	// Every entry takes at least a byte, which bounds the allocations.
+	if !ok1 || !ok2 || !ok3 || interval < 1 || interval > 1<<31 || entries > uint64(len(data)) || hasBases > 1 {
+		return ErrCorruptIndex
+	}
+
+	offsets := make([]int, entries)
+	var off uint64
+	for k := range offsets {
+		v, ok := get()
+		if !ok || off+v > 1<<62 {
+			return ErrCorruptIndex
+		}
+		off += v
+		offsets[k] = int(off)
+	}
+	var bases []uint32
+	if hasBases == 1 {
+		bases = make([]uint32, entries)
+		var base uint32
+		for k := range bases {
+			v, ok := get()
+			if !ok || v > 0xffffffff {
+				return ErrCorruptIndex
+			}
+			base += uint32(v)
+			bases[k] = base
+		}
+	}
+	if len(data) != 0 {
+		return ErrCorruptIndex
+	}
+
+	idx.Interval, idx.Offsets, idx.Bases = int(interval), offsets, bases
+	return nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestIndexOffsets(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	src := make([]uint32, 203)
+	for ix := range src {
+		src[ix] = r.Uint32() >> uint(8*r.Intn(4))
+	}
+
+	for _, interval := range []int{0, 1, 3, 16, 100} {
+		encoded, idx := EncodeIndexed(nil, src, interval)
+		clen := (len(src) + 3) / 4
+		ctrl := encoded[:clen]
+		if idx.Bases != nil {
+			t.Errorf("%d: unexpected bases\n", interval)
+		}
+		for k, off := range idx.Offsets {
+			if expected := skipData(ctrl[:k*idx.Interval]); off != expected {
+				t.Errorf("%d %d: %d != %d\n", interval, k, off, expected)
+			}
+		}
+		if err := idx.check(clen, len(encoded)-clen, false); err != nil {
+			t.Errorf("%d: %v\n", interval, err)
+		}
+	}
+}
+
+func TestIndexBases(t *testing.T) {
+	src := []uint32{3, 5, 8, 13, 21, 34, 55, 89, 144}
+	_, idx := EncodeDeltaIndexed(nil, src, 2, 1)
+	expected := []uint32{2, 13, 89}
+	if !reflect.DeepEqual(idx.Bases, expected) {
+		t.Errorf("%v != %v\n", idx.Bases, expected)
+	}
+}
+
+func TestIndexMarshal(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	src := make([]uint32, 999)
+	for ix := range src {
+		src[ix] = r.Uint32() >> uint(8*r.Intn(4))
+	}
+
+	for _, delta := range []bool{false, true} {
+		var idx *Index
+		if delta {
+			_, idx = EncodeDeltaIndexed(nil, src, 0, 7)
+		} else {
+			_, idx = EncodeIndexed(nil, src, 7)
+		}
+		b, err := idx.MarshalBinary()
+		if err != nil {
+			t.Fatalf("%v\n", err)
+		}
+		var out Index
+		if err := out.UnmarshalBinary(b); err != nil {
+			t.Errorf("%t: %v\n", delta, err)
+		}
+		if !reflect.DeepEqual(&out, idx) {
+			t.Errorf("%t: %v != %v\n", delta, out, *idx)
+		}
+
+		// Every truncation, and any trailing junk, has to be noticed.
+		for n := 0; n < len(b); n++ {
+			if err := out.UnmarshalBinary(b[:n]); err != ErrCorruptIndex {
+				t.Errorf("%t %d: %v\n", delta, n, err)
+			}
+		}
+		if err := out.UnmarshalBinary(append(b, 0)); err != ErrCorruptIndex {
+			t.Errorf("%t: %v\n", delta, err)
+		}
+	}
+}
+
+func TestIndexCheck(t *testing.T) {
+	src := make([]uint32, 40)
+	encoded, idx := EncodeIndexed(nil, src, 2)
+
+	if _, err := NewDeltaDecoder(encoded, len(src), 0, idx); err != ErrCorruptIndex {
+		t.Errorf("missing bases: %v\n", err)
+	}
+	if _, err := NewDecoder(encoded, len(src)+8, idx); err != ErrCorruptIndex {
+		t.Errorf("wrong entries: %v\n", err)
+	}
+	idx.Offsets[3] = 100
+	if _, err := NewDecoder(encoded, len(src), idx); err != ErrCorruptIndex {
+		t.Errorf("bad offset: %v\n", err)
+	}
+}
@@ -0,0 +1,267 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// The interleaved layout puts each control byte right in front of the data
+// bytes of its quad, instead of gathering all of the control bytes up
+// front, so that a quad can be written out as soon as its last value is
+// known. A trailing partial quad is padded out with zeros, and the stream
+// ends with a single trailer byte holding the number of values in the last
+// quad, modulo 4. (So an empty stream is just a zero byte.)
+
+// ErrCorruptTrailer is returned by InterleavedReader when the trailer byte
+// of a stream doesn't make sense.
+var ErrCorruptTrailer = errors.New("svb: corrupt interleaved stream trailer")
+
+// InterleavedWriter encodes a stream of uint32 values in the interleaved
+// layout. Each quad is written to the underlying io.Writer as soon as it is
+// complete, so at most 3 values are ever held back. Close must be called to
+// write the last of them, along with the trailer.
+type InterleavedWriter struct {
+	format Format
+	w      io.Writer
+	err    error
+
+	quad    [4]uint32
+	pending int
+	buf     [17]byte
+
+	valueSplitter
+}
+
+// NewInterleavedWriter returns an InterleavedWriter that writes to w.
+func NewInterleavedWriter(w io.Writer) *InterleavedWriter {
+	return Legacy.NewInterleavedWriter(w)
+}
+
+// NewInterleavedWriter is the Format-specific version of the package-level
+// NewInterleavedWriter.
+func (f Format) NewInterleavedWriter(w io.Writer) *InterleavedWriter {
+	return &InterleavedWriter{format: f, w: w}
+}
+
+// WriteUint32 adds a single value to the stream.
+func (z *InterleavedWriter) WriteUint32(v uint32) error {
+	if z.err != nil {
+		return z.err
+	}
+	z.quad[z.pending] = v
+	z.pending++
+	if z.pending < 4 {
+		return nil
+	}
+	return z.writeQuad()
+}
+
+// WriteUint32s adds all of vs to the stream.
+func (z *InterleavedWriter) WriteUint32s(vs []uint32) error {
+	for _, v := range vs {
+		if err := z.WriteUint32(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Write implements io.Writer, treating p as a sequence of little-endian
+// uint32 values. A value may be split across calls to Write.
+func (z *InterleavedWriter) Write(p []byte) (int, error) {
+	if z.err != nil {
+		return 0, z.err
+	}
+	return z.split(p, 4, func(b []byte) error {
+		return z.WriteUint32(binary.LittleEndian.Uint32(b))
+	})
+}
+
+// Close writes any values held back, and the trailer. Further writes will
+// return an error. If the bytes given to Write did not end on a value
+// boundary, Close returns ErrPartialValue.
+func (z *InterleavedWriter) Close() error {
+	if z.err == errWriterClosed {
+		return nil
+	}
+	if z.err != nil {
+		return z.err
+	}
+	trailer := byte(z.pending)
+	if z.pending > 0 {
+		for ix := z.pending; ix < 4; ix++ {
+			z.quad[ix] = 0
+		}
+		if err := z.writeQuad(); err != nil {
+			return err
+		}
+	}
+	if _, err := z.w.Write([]byte{trailer}); err != nil {
+		z.err = err
+		return err
+	}
+	z.err = errWriterClosed
+	if z.nrest > 0 {
+		return ErrPartialValue
+	}
+	return nil
+}
+
+// writeQuad writes the control byte and data bytes of the pending quad.
+func (z *InterleavedWriter) writeQuad() error {
+	ctrl, n := z.format.PutU32Block(z.buf[1:], z.quad[:], false)
+	z.buf[0] = ctrl
+	z.pending = 0
+	if _, err := z.w.Write(z.buf[:1+n]); err != nil {
+		z.err = err
+		return err
+	}
+	return nil
+}
+
+// InterleavedReader decodes a stream in the interleaved layout, as written
+// by InterleavedWriter, a quad at a time.
+type InterleavedReader struct {
+	format Format
+	r      *bufio.Reader
+	err    error
+
+	quad [4]uint32
+	pos  int
+	n    int
+	last bool
+}
+
+// NewInterleavedReader returns an InterleavedReader that reads from r. If r
+// is not a *bufio.Reader, it is wrapped in one, since spotting the trailer
+// needs a couple of bytes of lookahead.
+func NewInterleavedReader(r io.Reader) *InterleavedReader {
+	return Legacy.NewInterleavedReader(r)
+}
+
+// NewInterleavedReader is the Format-specific version of the package-level
+// NewInterleavedReader.
+func (f Format) NewInterleavedReader(r io.Reader) *InterleavedReader {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return &InterleavedReader{format: f, r: br}
+}
+
+// ReadUint32s decodes up to len(dst) values into dst, returning the number
+// of values decoded. At the end of the stream it returns 0 and io.EOF. A
+// stream that ends without its trailer results in io.ErrUnexpectedEOF,
+// except that there's no telling a trailer apart from a control byte under
+// 4 that happens to be the last byte left.
+func (z *InterleavedReader) ReadUint32s(dst []uint32) (int, error) {
+	var n int
+	for n < len(dst) {
+		if z.pos == z.n {
+			if z.err == nil {
+				z.err = z.next()
+			}
+			if z.err != nil {
+				if n > 0 {
+					return n, nil
+				}
+				return 0, z.err
+			}
+		}
+		c := copy(dst[n:], z.quad[z.pos:z.n])
+		z.pos += c
+		n += c
+	}
+	return n, nil
+}
+
+// ReadUint32 decodes a single value. At the end of the stream it returns
+// io.EOF.
+func (z *InterleavedReader) ReadUint32() (uint32, error) {
+	var v [1]uint32
+	if _, err := z.ReadUint32s(v[:]); err != nil {
+		return 0, err
+	}
+	return v[0], nil
+}
+
+// next reads the next quad. Once it has been read, a peek at what follows
+// tells whether it was the last one, and how much of it is padding.
+func (z *InterleavedReader) next() error {
+	if z.last {
+		return io.EOF
+	}
+	trailer, err := z.trailer()
+	if err != nil {
+		return err
+	}
+	if trailer >= 0 {
+		// The trailer of a stream that ends on a whole quad.
+		if trailer != 0 {
+			return ErrCorruptTrailer
+		}
+		return io.EOF
+	}
+
+	ctrl, err := z.r.ReadByte()
+	if err != nil {
+		return err
+	}
+	quad, err := z.format.ReadUint32s(ctrl, z.r)
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	} else if err != nil {
+		return err
+	}
+
+	// The quad only counts once what follows it checks out, so that an
+	// error here doesn't leave its values to be read on the next call.
+	trailer, err = z.trailer()
+	if err != nil {
+		return err
+	}
+	n := 4
+	switch {
+	case trailer > 3:
+		return ErrCorruptTrailer
+	case trailer > 0:
+		n = trailer
+	}
+	if trailer >= 0 {
+		z.r.ReadByte()
+		z.last = true
+	}
+	z.quad, z.pos, z.n = quad, 0, n
+	return nil
+}
+
+// trailer returns the trailer byte if it is all that is left of the
+// stream, or -1 if there is more to come.
+func (z *InterleavedReader) trailer() (int, error) {
+	b, err := z.r.Peek(2)
+	switch {
+	case len(b) == 2:
+		return -1, nil
+	case len(b) == 1 && err == io.EOF:
+		return int(b[0]), nil
+	case err == io.EOF:
+		return 0, io.ErrUnexpectedEOF
+	}
+	return 0, err
+}
@@ -0,0 +1,154 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// byteWriter records each call to Write, to check what is written when.
+type byteWriter struct {
+	writes [][]byte
+}
+
+func (w *byteWriter) Write(p []byte) (int, error) {
+	w.writes = append(w.writes, append([]byte(nil), p...))
+	return len(p), nil
+}
+
+func TestInterleavedLayout(t *testing.T) {
+	var w byteWriter
+	z := NewInterleavedWriter(&w)
+	z.WriteUint32s([]uint32{1, 1 << 8, 1 << 16, 1 << 24, 5})
+	if len(w.writes) != 1 {
+		t.Errorf("a whole quad should be written straight away: %d\n", len(w.writes))
+	}
+	z.Close()
+
+	expected := [][]byte{
+		{0x1b, 0x01, 0x01, 0x00, 0x01, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00},
+		{0x00, 0x05, 0x00, 0x00, 0x00},
+		{0x01},
+	}
+	if len(w.writes) != len(expected) {
+		t.Fatalf("%d != %d\n", len(w.writes), len(expected))
+	}
+	for ix := range expected {
+		if !bytes.Equal(w.writes[ix], expected[ix]) {
+			t.Errorf("%d: % x != % x\n", ix, w.writes[ix], expected[ix])
+		}
+	}
+}
+
+func TestInterleavedRoundtrip(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for _, f := range []Format{Legacy, Reference} {
+		for count := 0; count < 30; count++ {
+			src := make([]uint32, count)
+			for ix := range src {
+				src[ix] = r.Uint32() >> uint(8*r.Intn(4))
+			}
+
+			var buf bytes.Buffer
+			z := f.NewInterleavedWriter(&buf)
+			z.WriteUint32s(src)
+			if err := z.Close(); err != nil {
+				t.Fatalf("unexpected: %v\n", err)
+			}
+			encoded := buf.Bytes()
+
+			var vals []uint32
+			rd := f.NewInterleavedReader(bytes.NewReader(encoded))
+			dst := make([]uint32, 3)
+			for {
+				n, err := rd.ReadUint32s(dst)
+				vals = append(vals, dst[:n]...)
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("%v %d: %v\n", f, count, err)
+				}
+			}
+			if len(vals) != len(src) {
+				t.Fatalf("%v %d: %v != %v\n", f, count, vals, src)
+			}
+			for ix := range src {
+				if vals[ix] != src[ix] {
+					t.Errorf("%v %d: %v != %v\n", f, count, vals, src)
+					break
+				}
+			}
+
+			// Truncation has to be noticed, unless the last byte left
+			// passes for a trailer.
+			for n := 0; n < len(encoded); n++ {
+				rd := f.NewInterleavedReader(bytes.NewReader(encoded[:n]))
+				var err error
+				for err == nil {
+					_, err = rd.ReadUint32()
+				}
+				if err == io.EOF && (n == 0 || encoded[n-1] > 3) {
+					t.Errorf("%v %d: truncated at %d\n", f, count, n)
+				}
+			}
+		}
+	}
+}
+
+func TestInterleavedTrailer(t *testing.T) {
+	for _, encoded := range [][]byte{
+		{0x04},
+		{0x00, 0x01, 0x02, 0x03, 0x04, 0x05},
+		{0x00, 0x01, 0x02, 0x03, 0x04, 0x09},
+	} {
+		rd := NewInterleavedReader(bytes.NewReader(encoded))
+		var err error
+		for err == nil {
+			_, err = rd.ReadUint32()
+		}
+		if err != ErrCorruptTrailer {
+			t.Errorf("% x: %v != %v\n", encoded, err, ErrCorruptTrailer)
+		}
+
+		// The quad in front of a bad trailer must not turn up later.
+		dst := make([]uint32, 4)
+		if n, err := rd.ReadUint32s(dst); n != 0 || err != ErrCorruptTrailer {
+			t.Errorf("% x: %d, %v != 0, %v\n", encoded, n, err, ErrCorruptTrailer)
+		}
+	}
+}
+
+func TestInterleavedWrite(t *testing.T) {
+	var buf bytes.Buffer
+	z := NewInterleavedWriter(&buf)
+	z.Write([]byte{0x04, 0x03})
+	z.Write([]byte{0x02, 0x01, 0xff})
+	if err := z.Close(); err != ErrPartialValue {
+		t.Errorf("%v != %v\n", err, ErrPartialValue)
+	}
+	if err := z.WriteUint32(1); err == nil {
+		t.Errorf("expected an error writing after Close\n")
+	}
+
+	v, err := NewInterleavedReader(&buf).ReadUint32()
+	if err != nil || v != 0x01020304 {
+		t.Errorf("%#x, %v\n", v, err)
+	}
+}
@@ -14,10 +14,10 @@
 
 package svb
 
-// lengths is the lookup table that is the shortcut to get from a control
-// byte to knowing the 4 individual data byte lengths (in index 0 - 3), as
-// well as the total data bytes needed for the given block (in index 4)
-var lookup = map[byte][4]uint8{
+// lookup is the table that is the shortcut to get from a control byte to
+// knowing the 4 individual data byte lengths (in index 0 - 3). The total data
+// bytes needed for the given block are in lookupTotal.
+var lookup = [256][4]uint8{
 	0x00: [4]uint8{1, 1, 1, 1},
 	0x01: [4]uint8{1, 1, 1, 2},
 	0x02: [4]uint8{1, 1, 1, 3},
@@ -275,3 +275,45 @@ var lookup = map[byte][4]uint8{
 	0xfe: [4]uint8{4, 4, 4, 3},
 	0xff: [4]uint8{4, 4, 4, 4},
 }
+
+// refLookup is the Reference format counterpart to lookup. Reversing the
+// order of the 2-bit codes in a control byte also reverses the order of the
+// lengths, so each entry is simply the reverse of the Legacy entry.
+var refLookup = reverseLengths(&lookup)
+
+// lookupTotal is the total number of data bytes in the block that a control
+// byte describes. The total doesn't depend on the order of the lengths, so
+// it applies to both formats.
+var lookupTotal = totalLengths(&lookup)
+
+// lookupOffset and refLookupOffset are where each value of the block that a
+// control byte describes starts within the data of that block.
+var (
+	lookupOffset    = lengthOffsets(&lookup)
+	refLookupOffset = lengthOffsets(&refLookup)
+)
+
+func reverseLengths(table *[256][4]uint8) (rev [256][4]uint8) {
+	for ctrl, blens := range table {
+		rev[ctrl] = [4]uint8{blens[3], blens[2], blens[1], blens[0]}
+	}
+	return rev
+}
+
+func totalLengths(table *[256][4]uint8) (totals [256]uint8) {
+	for ctrl, blens := range table {
+		totals[ctrl] = blens[0] + blens[1] + blens[2] + blens[3]
+	}
+	return totals
+}
+
+func lengthOffsets(table *[256][4]uint8) (offs [256][4]uint8) {
+	for ctrl, blens := range table {
+		var off uint8
+		for ix, blen := range blens {
+			offs[ctrl][ix] = off
+			off += blen
+		}
+	}
+	return offs
+}
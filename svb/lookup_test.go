@@ -29,3 +29,21 @@ func TestLengths(t *testing.T) {
 		}
 	}
 }
+
+func TestLookupTotalsAndOffsets(t *testing.T) {
+	for _, f := range []Format{Legacy, Reference} {
+		lens, offs := f.table(), f.offsets()
+		for ctrl := range lens {
+			var off uint8
+			for ix := range lens[ctrl] {
+				if offs[ctrl][ix] != off {
+					t.Errorf("%v %#x, %d: %d != %d\n", f, ctrl, ix, offs[ctrl][ix], off)
+				}
+				off += lens[ctrl][ix]
+			}
+			if lookupTotal[ctrl] != off {
+				t.Errorf("%v %#x: %d != %d\n", f, ctrl, lookupTotal[ctrl], off)
+			}
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import (
+	"runtime"
+	"sync"
+)
+
+// minParallelQuads is the smallest number of quads worth handing to a
+// worker of its own; anything less is encoded in one go.
+const minParallelQuads = 4096
+
+// EncodeParallel is like Encode, but splits src into runs of whole quads
+// that are encoded concurrently by up to workers goroutines, then stitched
+// back together. The result is byte for byte the same as Encode's. If
+// workers is less than 1, GOMAXPROCS is used.
+func EncodeParallel(dst []byte, src []uint32, workers int) []byte {
+	return Legacy.EncodeParallel(dst, src, workers)
+}
+
+// EncodeParallel is the Format-specific version of the package-level
+// EncodeParallel.
+func (f Format) EncodeParallel(dst []byte, src []uint32, workers int) []byte {
+	return Options{Format: f}.encodeParallel(dst, src, 0, workers)
+}
+
+// EncodeDeltaParallel is the parallel version of EncodeDelta. Each run is
+// encoded on its own, taking the last value of the run before it as its
+// base, and the result is byte for byte the same as EncodeDelta's.
+func EncodeDeltaParallel(dst []byte, src []uint32, prev uint32, workers int) []byte {
+	return Legacy.EncodeDeltaParallel(dst, src, prev, workers)
+}
+
+// EncodeDeltaParallel is the Format-specific version of the package-level
+// EncodeDeltaParallel.
+func (f Format) EncodeDeltaParallel(dst []byte, src []uint32, prev uint32, workers int) []byte {
+	return Options{Format: f, Delta: true}.encodeParallel(dst, src, prev, workers)
+}
+
+// MarshalParallel is the parallel version of Marshal, with any combination
+// of options. The result is byte for byte the same as Marshal's.
+func MarshalParallel(dst []byte, src []uint32, opts Options, workers int) []byte {
+	return marshal(dst, src, opts, workers)
+}
+
+// encodeParallel encodes src in the bare layout the options call for,
+// using up to workers goroutines.
+func (o Options) encodeParallel(dst []byte, src []uint32, prev uint32, workers int) []byte {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	quads := (len(src) + 3) / 4
+	per := (quads + workers - 1) / workers
+	if per < minParallelQuads {
+		per = minParallelQuads
+	}
+	if per >= quads {
+		return o.encode(dst, src, prev)
+	}
+
+	// Each run starts on a quad boundary, so only the last one can end in
+	// a partial quad, just as in the sequential encoding. And since each
+	// run is given the value before it as its base, the differences come
+	// out the same too.
+	//
+	// The runs are gone over twice: first to size up their data bytes,
+	// which is all it takes to know where each run's control and data bytes
+	// go, and then to encode them straight into place.
+	nruns := (quads + per - 1) / per
+	eachRun := func(fn func(k int, vals []uint32, base uint32)) {
+		var wg sync.WaitGroup
+		for k := 0; k < nruns; k++ {
+			start, end := 4*k*per, 4*(k+1)*per
+			if end > len(src) {
+				end = len(src)
+			}
+			base := prev
+			if start > 0 {
+				base = src[start-1]
+			}
+			wg.Add(1)
+			go func(k int, vals []uint32, base uint32) {
+				defer wg.Done()
+				fn(k, vals, base)
+			}(k, src[start:end], base)
+		}
+		wg.Wait()
+	}
+
+	sizes := make([]int, nruns)
+	eachRun(func(k int, vals []uint32, base uint32) {
+		sizes[k] = o.encodedDataLen(vals, base)
+	})
+
+	// The control bytes of every run go together at the front, followed by
+	// all of the data bytes.
+	offsets := make([]int, nruns)
+	n := quads
+	for k, size := range sizes {
+		offsets[k] = n
+		n += size
+	}
+	if cap(dst) < n {
+		dst = make([]byte, n)
+	} else {
+		dst = dst[:n]
+	}
+	eachRun(func(k int, vals []uint32, base uint32) {
+		ctrl := dst[k*per : k*per+runQuads(k, per, quads)]
+		end := offsets[k] + sizes[k]
+		o.encodeTo(ctrl, dst[offsets[k]:end:end], vals, base)
+	})
+	return dst
+}
+
+// runQuads is the number of quads in the k-th run of a parallel encoding.
+func runQuads(k, per, quads int) int {
+	if (k+1)*per > quads {
+		return quads - k*per
+	}
+	return per
+}
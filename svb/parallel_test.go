@@ -0,0 +1,78 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestEncodeParallel(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for _, size := range []int{0, 7, 4 * minParallelQuads, 4*minParallelQuads*3 + 5} {
+		src := make([]uint32, size)
+		var sum uint32
+		for ix := range src {
+			sum += r.Uint32() >> uint(8*r.Intn(4)+8)
+			src[ix] = sum
+		}
+
+		for _, f := range []Format{Legacy, Reference} {
+			plain, delta := f.Encode(nil, src), f.EncodeDelta(nil, src, 3)
+			for _, workers := range []int{0, 1, 2, 3, 8} {
+				if out := f.EncodeParallel(nil, src, workers); !bytes.Equal(out, plain) {
+					t.Errorf("%v %d %d: plain mismatch\n", f, size, workers)
+				}
+				if out := f.EncodeDeltaParallel(nil, src, 3, workers); !bytes.Equal(out, delta) {
+					t.Errorf("%v %d %d: delta mismatch\n", f, size, workers)
+				}
+			}
+		}
+
+		for _, opts := range containerOptions() {
+			expected := Marshal(nil, src, opts)
+			if out := MarshalParallel(nil, src, opts, 4); !bytes.Equal(out, expected) {
+				t.Errorf("%+v %d: mismatch\n", opts, size)
+			}
+		}
+	}
+}
+
+func TestEncodeParallelReusesDst(t *testing.T) {
+	src := make([]uint32, 4*minParallelQuads*2)
+	for ix := range src {
+		src[ix] = uint32(ix)
+	}
+	dst := make([]byte, MaxEncodedLen(len(src)))
+	if out := EncodeParallel(dst, src, 2); &out[0] != &dst[0] {
+		t.Errorf("dst was not reused\n")
+	}
+}
+
+func BenchmarkEncodeParallel(b *testing.B) {
+	src, _ := benchmarkStream(Legacy, 1<<20)
+	dst := make([]byte, MaxEncodedLen(len(src)))
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprint(workers), func(b *testing.B) {
+			b.SetBytes(int64(4 * len(src)))
+			for i := 0; i < b.N; i++ {
+				EncodeParallel(dst, src, workers)
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import (
+	"errors"
+	"sort"
+)
+
+// ErrUnsorted is returned when a posting list is built from values that
+// aren't in strictly ascending order.
+var ErrUnsorted = errors.New("svb: values are not strictly ascending")
+
+// postingInterval is the number of quads in each block of a posting list,
+// which is also the spacing of its skip index entries.
+const postingInterval = 8
+
+// PostingList is a compressed set of uint32s, such as the document IDs of an
+// inverted index. The values are delta encoded (carried across the whole
+// list) and split into blocks by a skip index. Since the values are sorted,
+// the index also gives the range each block covers, so the set operations
+// only decode blocks that can overlap the other list.
+//
+// A PostingList is immutable, and safe for concurrent use.
+type PostingList struct {
+	format Format
+	count  int
+	src    []byte
+	index  *Index
+	last   uint32
+}
+
+// NewPostingList builds a posting list from values in strictly ascending
+// order.
+func NewPostingList(vals []uint32) (*PostingList, error) {
+	return Legacy.NewPostingList(vals)
+}
+
+// NewPostingList is the Format-specific version of the package-level
+// NewPostingList.
+func (f Format) NewPostingList(vals []uint32) (*PostingList, error) {
+	for ix := 1; ix < len(vals); ix++ {
+		if vals[ix] <= vals[ix-1] {
+			return nil, ErrUnsorted
+		}
+	}
+	return f.newPostingList(vals), nil
+}
+
+// newPostingList builds a posting list from values that are known to be in
+// order.
+func (f Format) newPostingList(vals []uint32) *PostingList {
+	src, index := f.EncodeDeltaIndexed(nil, vals, 0, postingInterval)
+	p := &PostingList{format: f, count: len(vals), src: src, index: index}
+	if len(vals) > 0 {
+		p.last = vals[len(vals)-1]
+	}
+	return p
+}
+
+// Len returns the number of values in the list.
+func (p *PostingList) Len() int {
+	return p.count
+}
+
+// Values decodes the whole list. As with Decode, the storage of dst is
+// reused when it is large enough.
+func (p *PostingList) Values(dst []uint32) []uint32 {
+	// The list was encoded by this package, so it can't be short.
+	out, _ := p.format.DecodeDelta(dst, p.src, p.count, 0)
+	return out
+}
+
+// Contains reports whether v is in the list, decoding at most one block.
+func (p *PostingList) Contains(v uint32) bool {
+	c := p.cursor()
+	k := sort.Search(c.blocks(), func(k int) bool {
+		return c.high(k) >= v
+	})
+	if k == c.blocks() || c.low(k) > v {
+		return false
+	}
+	c.seek(k)
+	vals := c.vals
+	ix := sort.Search(len(vals), func(ix int) bool {
+		return vals[ix] >= v
+	})
+	return ix < len(vals) && vals[ix] == v
+}
+
+// Intersect returns the values that are in both p and q. Blocks of either
+// list that don't overlap any block of the other are skipped without being
+// decoded.
+func (p *PostingList) Intersect(q *PostingList) *PostingList {
+	a, b := p.cursor(), q.cursor()
+	var out []uint32
+	for !a.done() && !b.done() {
+		switch {
+		case a.high(a.k) < b.low(b.k):
+			a.next()
+		case b.high(b.k) < a.low(a.k):
+			b.next()
+		default:
+			x, y := a.value(), b.value()
+			if x <= y {
+				a.advance()
+			}
+			if y <= x {
+				b.advance()
+			}
+			if x == y {
+				out = append(out, x)
+			}
+		}
+	}
+	return p.format.newPostingList(out)
+}
+
+// Union returns the values that are in either p or q. Every block has to be
+// decoded, but blocks that don't overlap the other list are copied over
+// whole, rather than merged value by value.
+func (p *PostingList) Union(q *PostingList) *PostingList {
+	a, b := p.cursor(), q.cursor()
+	out := make([]uint32, 0, p.count+q.count)
+	for !a.done() && !b.done() {
+		switch {
+		case a.high(a.k) < b.low(b.k):
+			out = a.rest(out)
+		case b.high(b.k) < a.low(a.k):
+			out = b.rest(out)
+		default:
+			x, y := a.value(), b.value()
+			if x <= y {
+				a.advance()
+			}
+			if y <= x {
+				b.advance()
+			}
+			if x < y {
+				out = append(out, x)
+			} else {
+				out = append(out, y)
+			}
+		}
+	}
+	for !a.done() {
+		out = a.rest(out)
+	}
+	for !b.done() {
+		out = b.rest(out)
+	}
+	return p.format.newPostingList(out)
+}
+
+// Difference returns the values of p that are not in q. Only the blocks of
+// q that overlap a block of p are decoded.
+func (p *PostingList) Difference(q *PostingList) *PostingList {
+	a, b := p.cursor(), q.cursor()
+	out := make([]uint32, 0, p.count)
+	for !a.done() {
+		switch {
+		case b.done() || a.high(a.k) < b.low(b.k):
+			out = a.rest(out)
+		case b.high(b.k) < a.low(a.k):
+			b.next()
+		default:
+			x, y := a.value(), b.value()
+			if x < y {
+				out = append(out, x)
+			}
+			if x <= y {
+				a.advance()
+			}
+			if y <= x {
+				b.advance()
+			}
+		}
+	}
+	return p.format.newPostingList(out)
+}
+
+// cursor returns a blockCursor at the start of the list.
+func (p *PostingList) cursor() *blockCursor {
+	// The list was encoded by this package, so its index always fits.
+	d, _ := p.format.NewDeltaDecoder(p.src, p.count, 0, p.index)
+	return &blockCursor{p: p, d: d}
+}
+
+// blockCursor walks through a posting list a block at a time, only decoding
+// a block once one of its values is asked for.
+type blockCursor struct {
+	p      *PostingList
+	d      *Decoder
+	k      int
+	loaded bool
+	vals   []uint32
+	pos    int
+	buf    [4 * postingInterval]uint32
+}
+
+// blocks returns the number of blocks in the list.
+func (c *blockCursor) blocks() int {
+	return len(c.p.index.Offsets)
+}
+
+// done reports whether the cursor has moved past the last block.
+func (c *blockCursor) done() bool {
+	return c.k >= c.blocks()
+}
+
+// low returns a lower bound for the values in block k that haven't been
+// passed yet. Without decoding, that's the value just before the block.
+func (c *blockCursor) low(k int) uint32 {
+	if k == c.k && c.loaded {
+		return c.vals[c.pos]
+	}
+	return c.p.index.Bases[k]
+}
+
+// high returns the largest value in block k, which is the value just
+// before the next block.
+func (c *blockCursor) high(k int) uint32 {
+	if k+1 < c.blocks() {
+		return c.p.index.Bases[k+1]
+	}
+	return c.p.last
+}
+
+// seek moves to the start of block k, and decodes it.
+func (c *blockCursor) seek(k int) {
+	start := 4 * postingInterval * k
+	end := start + len(c.buf)
+	if end > c.p.count {
+		end = c.p.count
+	}
+	c.d.Seek(start)
+	c.d.Read(c.buf[:end-start])
+	c.k, c.loaded, c.vals, c.pos = k, true, c.buf[:end-start], 0
+}
+
+// value returns the current value, decoding its block if need be.
+func (c *blockCursor) value() uint32 {
+	if !c.loaded {
+		c.seek(c.k)
+	}
+	return c.vals[c.pos]
+}
+
+// advance moves past the current value.
+func (c *blockCursor) advance() {
+	c.pos++
+	if c.pos == len(c.vals) {
+		c.next()
+	}
+}
+
+// next moves to the start of the next block, without decoding it.
+func (c *blockCursor) next() {
+	c.k++
+	c.loaded = false
+}
+
+// rest appends the values left in the current block to out, and moves to
+// the next block.
+func (c *blockCursor) rest(out []uint32) []uint32 {
+	if !c.loaded {
+		c.seek(c.k)
+	}
+	out = append(out, c.vals[c.pos:]...)
+	c.next()
+	return out
+}
@@ -0,0 +1,122 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// randomSet returns size distinct values below limit, in ascending order.
+func randomSet(r *rand.Rand, size int, base, limit uint32) []uint32 {
+	seen := make(map[uint32]bool)
+	for len(seen) < size {
+		seen[base+uint32(r.Int63n(int64(limit-base)))] = true
+	}
+	vals := make([]uint32, 0, size)
+	for v := range seen {
+		vals = append(vals, v)
+	}
+	sort.Slice(vals, func(i, j int) bool { return vals[i] < vals[j] })
+	return vals
+}
+
+func TestPostingListUnsorted(t *testing.T) {
+	for _, vals := range [][]uint32{{1, 1}, {5, 4}, {1, 2, 3, 3}} {
+		if _, err := NewPostingList(vals); err != ErrUnsorted {
+			t.Errorf("%v: %v\n", vals, err)
+		}
+	}
+}
+
+func TestPostingListContains(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for _, f := range []Format{Legacy, Reference} {
+		vals := randomSet(r, 500, 0, 2000)
+		p, err := f.NewPostingList(vals)
+		if err != nil {
+			t.Fatalf("%v\n", err)
+		}
+		if p.Len() != len(vals) || !reflect.DeepEqual(p.Values(nil), vals) {
+			t.Errorf("%v: %v != %v\n", f, p.Values(nil), vals)
+		}
+
+		set := make(map[uint32]bool)
+		for _, v := range vals {
+			set[v] = true
+		}
+		for v := uint32(0); v < 2100; v++ {
+			if p.Contains(v) != set[v] {
+				t.Errorf("%v %d: %t != %t\n", f, v, p.Contains(v), set[v])
+			}
+		}
+	}
+}
+
+func TestPostingListSetOps(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for _, tc := range []struct {
+		sizeA, baseA, limitA int
+		sizeB, baseB, limitB int
+	}{
+		{0, 0, 10, 0, 0, 10},
+		{10, 0, 100, 0, 0, 10},
+		{0, 0, 10, 10, 0, 100},
+		{300, 0, 1000, 300, 0, 1000},
+		{300, 0, 1000, 30, 0, 1000},
+		{200, 0, 1000, 200, 500, 1500},
+		{200, 0, 1000, 200, 1000, 2000},
+		{1000, 0, 1 << 30, 1000, 0, 1 << 30},
+	} {
+		a := randomSet(r, tc.sizeA, uint32(tc.baseA), uint32(tc.limitA))
+		b := randomSet(r, tc.sizeB, uint32(tc.baseB), uint32(tc.limitB))
+		inB := make(map[uint32]bool)
+		for _, v := range b {
+			inB[v] = true
+		}
+
+		var and, or, not []uint32
+		for _, v := range a {
+			if inB[v] {
+				and = append(and, v)
+			} else {
+				not = append(not, v)
+				or = append(or, v)
+			}
+		}
+		or = append(or, b...)
+		sort.Slice(or, func(i, j int) bool { return or[i] < or[j] })
+
+		pa, _ := NewPostingList(a)
+		pb, _ := NewPostingList(b)
+		for _, op := range []struct {
+			name     string
+			out      *PostingList
+			expected []uint32
+		}{
+			{"intersect", pa.Intersect(pb), and},
+			{"union", pa.Union(pb), or},
+			{"difference", pa.Difference(pb), not},
+		} {
+			out := op.out.Values(nil)
+			if len(out) != len(op.expected) || (len(out) > 0 && !reflect.DeepEqual(out, op.expected)) {
+				t.Errorf("%+v %s: %v != %v\n", tc, op.name, out, op.expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// maxChunkValues bounds the value count a Reader will accept from a chunk
+// header, so that a corrupt header can't trigger a huge allocation.
+const maxChunkValues = 1 << 26
+
+// ErrCorruptChunk is returned by Reader when a chunk header is inconsistent.
+var ErrCorruptChunk = errors.New("svb: corrupt chunk header")
+
+// byteReader is what Reader needs from its source: bulk reads for the
+// control and data sections, and single bytes for the uvarint header.
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// newByteReader returns r as a byteReader, wrapping it in a bufio.Reader if
+// need be.
+func newByteReader(r io.Reader) byteReader {
+	if br, ok := r.(byteReader); ok {
+		return br
+	}
+	return bufio.NewReader(r)
+}
+
+// Reader decodes the chunked stream produced by Writer, one chunk at a time,
+// so that the whole stream never needs to be held in memory.
+type Reader struct {
+	format Format
+	r      byteReader
+	buf    []byte
+	vals   []uint32
+	pos    int
+	err    error
+}
+
+// NewReader returns a Reader that decodes the chunks read from r. If r does
+// not implement io.ByteReader, it is wrapped in a bufio.Reader.
+func NewReader(r io.Reader) *Reader {
+	return Legacy.NewReader(r)
+}
+
+// NewReader is the Format-specific version of the package-level NewReader.
+// It decodes the chunks written by a Writer of the same Format.
+func (f Format) NewReader(r io.Reader) *Reader {
+	return &Reader{format: f, r: newByteReader(r)}
+}
+
+// ReadUint32s decodes up to len(dst) values into dst, returning the number
+// of values decoded. At the end of the stream it returns 0 and io.EOF. A
+// stream that ends in the middle of a chunk results in io.ErrUnexpectedEOF.
+func (z *Reader) ReadUint32s(dst []uint32) (int, error) {
+	if len(dst) == 0 {
+		return 0, nil
+	}
+	for z.pos == len(z.vals) {
+		if z.err != nil {
+			return 0, z.err
+		}
+		z.err = z.nextChunk()
+	}
+	n := copy(dst, z.vals[z.pos:])
+	z.pos += n
+	return n, nil
+}
+
+// ReadUint32 decodes a single value. At the end of the stream it returns
+// io.EOF.
+func (z *Reader) ReadUint32() (uint32, error) {
+	var v [1]uint32
+	if _, err := z.ReadUint32s(v[:]); err != nil {
+		return 0, err
+	}
+	return v[0], nil
+}
+
+// nextChunk reads and decodes the next chunk from the underlying reader.
+func (z *Reader) nextChunk() error {
+	z.vals, z.pos = z.vals[:0], 0
+
+	count, buf, err := readChunk(z.r, z.buf, 4, func(count int) int {
+		return (count + 3) / 4
+	})
+	z.buf = buf
+	if err != nil {
+		return err
+	}
+	z.vals, err = z.format.Decode(z.vals, z.buf, count)
+	return err
+}
+
+// readChunk reads the header of the next chunk, and then its control and
+// data sections into buf (which is grown as needed). Each value is expected
+// to take between 1 and width data bytes, and ctrlLen gives the size of the
+// control section for a given count of values.
+func readChunk(r byteReader, buf []byte, width int, ctrlLen func(int) int) (int, []byte, error) {
+	count, err := binary.ReadUvarint(r)
+	if err != nil {
+		return 0, buf, err
+	}
+	dlen, err := binary.ReadUvarint(r)
+	if err == io.EOF {
+		return 0, buf, io.ErrUnexpectedEOF
+	} else if err != nil {
+		return 0, buf, err
+	}
+	if count > maxChunkValues || dlen < count || dlen > uint64(width)*count {
+		return 0, buf, ErrCorruptChunk
+	}
+
+	size := ctrlLen(int(count)) + int(dlen)
+	if cap(buf) < size {
+		buf = make([]byte, size)
+	}
+	buf = buf[:size]
+	if _, err := io.ReadFull(r, buf); err != nil {
+		if err == io.EOF {
+			return 0, buf, io.ErrUnexpectedEOF
+		}
+		return 0, buf, err
+	}
+	return int(count), buf, nil
+}
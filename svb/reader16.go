@@ -0,0 +1,74 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import "io"
+
+// Reader16 is the 16-bit counterpart to Reader, decoding the chunked stream
+// produced by Writer16.
+type Reader16 struct {
+	r    byteReader
+	buf  []byte
+	vals []uint16
+	pos  int
+	err  error
+}
+
+// NewReader16 returns a Reader16 that decodes the chunks read from r. If r
+// does not implement io.ByteReader, it is wrapped in a bufio.Reader.
+func NewReader16(r io.Reader) *Reader16 {
+	return &Reader16{r: newByteReader(r)}
+}
+
+// ReadUint16s decodes up to len(dst) values into dst, returning the number
+// of values decoded. At the end of the stream it returns 0 and io.EOF. A
+// stream that ends in the middle of a chunk results in io.ErrUnexpectedEOF.
+func (z *Reader16) ReadUint16s(dst []uint16) (int, error) {
+	if len(dst) == 0 {
+		return 0, nil
+	}
+	for z.pos == len(z.vals) {
+		if z.err != nil {
+			return 0, z.err
+		}
+		z.err = z.nextChunk()
+	}
+	n := copy(dst, z.vals[z.pos:])
+	z.pos += n
+	return n, nil
+}
+
+// ReadUint16 decodes a single value. At the end of the stream it returns
+// io.EOF.
+func (z *Reader16) ReadUint16() (uint16, error) {
+	var v [1]uint16
+	if _, err := z.ReadUint16s(v[:]); err != nil {
+		return 0, err
+	}
+	return v[0], nil
+}
+
+// nextChunk reads and decodes the next chunk from the underlying reader.
+func (z *Reader16) nextChunk() error {
+	z.vals, z.pos = z.vals[:0], 0
+
+	count, buf, err := readChunk(z.r, z.buf, 2, ctrlLen16)
+	z.buf = buf
+	if err != nil {
+		return err
+	}
+	z.vals, err = Decode16(z.vals, z.buf, count)
+	return err
+}
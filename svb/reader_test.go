@@ -0,0 +1,92 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestReaderRoundtrip(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	src := make([]uint32, 10007)
+	for ix := range src {
+		src[ix] = r.Uint32() >> uint(8*r.Intn(4))
+	}
+
+	for _, f := range []Format{Legacy, Reference} {
+		var buf bytes.Buffer
+		w := f.NewWriterSize(&buf, 1000)
+		w.WriteUint32s(src)
+		w.Close()
+
+		var vals []uint32
+		rd := f.NewReader(&buf)
+		dst := make([]uint32, 333)
+		for {
+			n, err := rd.ReadUint32s(dst)
+			vals = append(vals, dst[:n]...)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%v: unexpected: %v\n", f, err)
+			}
+		}
+
+		if len(vals) != len(src) {
+			t.Fatalf("%v len: %d != %d\n", f, len(vals), len(src))
+		}
+		for ix := range src {
+			if vals[ix] != src[ix] {
+				t.Fatalf("%v %d: %d != %d\n", f, ix, vals[ix], src[ix])
+			}
+		}
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	tests := []struct {
+		data []byte
+		err  error
+	}{
+		{ // Empty stream
+			[]byte{},
+			io.EOF,
+		},
+		{ // Missing data length
+			[]byte{0x02},
+			io.ErrUnexpectedEOF,
+		},
+		{ // Missing the last data byte
+			[]byte{0x02, 0x02, 0x00, 0x01},
+			io.ErrUnexpectedEOF,
+		},
+		{ // More data bytes than 2 values could ever need
+			[]byte{0x02, 0x09},
+			ErrCorruptChunk,
+		},
+	}
+
+	for _, test := range tests {
+		_, err := NewReader(bytes.NewReader(test.data)).ReadUint32()
+		if err != test.err {
+			t.Errorf("% x: %v != %v\n", test.data, err, test.err)
+		}
+	}
+}
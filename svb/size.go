@@ -0,0 +1,52 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+// MaxEncodedLen returns the largest number of bytes that Encode (or
+// EncodeDelta) could need for count values: a control byte for every quad,
+// and 4 data bytes for every value.
+func MaxEncodedLen(count int) int {
+	return (count+3)/4 + 4*count
+}
+
+// EncodedLen returns exactly how many bytes Encode will produce for src. It
+// is the same in both formats.
+func EncodedLen(src []uint32) int {
+	n := (len(src) + 3) / 4
+	for _, num := range src {
+		n += int(byteLength(num))
+	}
+	return n
+}
+
+// DataLenFromControl returns how many data bytes follow the control bytes
+// in ctrl, for a stream of count values. This allows a payload to be
+// validated (or a buffer to be sized) before decoding. It returns -1 when
+// ctrl holds too few control bytes for count values.
+func DataLenFromControl(ctrl []byte, count int) int {
+	return Legacy.DataLenFromControl(ctrl, count)
+}
+
+// DataLenFromControl is the Format-specific version of the package-level
+// DataLenFromControl. (The format matters for a trailing partial quad,
+// since it decides which of the control byte's codes are in use.)
+func (f Format) DataLenFromControl(ctrl []byte, count int) int {
+	// Bounding the count by ctrl first keeps a huge count from
+	// overflowing the control length.
+	if count < 0 || count > 4*len(ctrl) {
+		return -1
+	}
+	return f.dataLen(ctrl, count)
+}
@@ -0,0 +1,90 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestMaxEncodedLen(t *testing.T) {
+	tests := []struct {
+		count int
+		size  int
+	}{
+		{0, 0},
+		{1, 5},
+		{4, 17},
+		{5, 22},
+	}
+
+	for _, test := range tests {
+		if size := MaxEncodedLen(test.count); size != test.size {
+			t.Errorf("%d: %d != %d\n", test.count, size, test.size)
+		}
+	}
+}
+
+func TestEncodedLen(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for count := 0; count < 50; count++ {
+		src := make([]uint32, count)
+		for ix := range src {
+			src[ix] = r.Uint32() >> uint(8*r.Intn(4))
+		}
+
+		size := EncodedLen(src)
+		for _, f := range []Format{Legacy, Reference} {
+			encoded := f.Encode(nil, src)
+			if size != len(encoded) {
+				t.Errorf("%v: %d != %d\n", f, size, len(encoded))
+			}
+			if size > MaxEncodedLen(count) {
+				t.Errorf("%v: %d > %d\n", f, size, MaxEncodedLen(count))
+			}
+
+			clen := (count + 3) / 4
+			if dlen := f.DataLenFromControl(encoded[:clen], count); dlen != size-clen {
+				t.Errorf("%v: %d != %d\n", f, dlen, size-clen)
+			}
+		}
+	}
+}
+
+func TestDataLenFromControl(t *testing.T) {
+	tests := []struct {
+		format Format
+		ctrl   []byte
+		count  int
+		size   int
+	}{
+		{Legacy, []byte{}, 0, 0},
+		{Legacy, []byte{0x43}, 4, 8},
+		{Legacy, []byte{0x43}, 1, 2},    // only the 01
+		{Reference, []byte{0x43}, 1, 4}, // only the 11
+		{Legacy, []byte{0x43}, 5, -1},
+		{Legacy, []byte{0x43}, -1, -1},
+		{Legacy, []byte{0x43}, math.MaxInt64, -1},
+		{Reference, []byte{0x43}, math.MaxInt64 - 3, -1},
+	}
+
+	for _, test := range tests {
+		if size := test.format.DataLenFromControl(test.ctrl, test.count); size != test.size {
+			t.Errorf("%v % x, %d: %d != %d\n", test.format, test.ctrl, test.count, size, test.size)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import "errors"
+
+// ErrTrailingData is returned by AnalyzeEncoded when there are more data
+// bytes than the control bytes account for.
+var ErrTrailingData = errors.New("svb: more data bytes than the control bytes describe")
+
+// Stats describes how a sequence of values encodes, for tuning which
+// options to use.
+type Stats struct {
+	// Count is the number of values.
+	Count int
+
+	// Lengths counts the values by their 2-bit length code. For the
+	// standard variant, code c means c+1 bytes; for the 0124 variant, the
+	// codes mean 0, 1, 2 and 4 bytes.
+	Lengths [4]int
+
+	// Control counts how often each control byte is used.
+	Control [256]int
+
+	// Size is the number of control and data bytes the values take up.
+	Size int
+
+	// Modes gives the bytes per value that each combination of the Delta
+	// and Zigzag options would take. It is only filled in by Analyze.
+	Modes ModeCosts
+
+	// Recommended is the combination of options that gives the smallest
+	// encoding, keeping the format, variant and checksum that were asked
+	// for. It is only filled in by Analyze.
+	Recommended Options
+}
+
+// ModeCosts holds the bytes per value, including control bytes, of each
+// combination of the Delta and Zigzag options.
+type ModeCosts struct {
+	Plain       float64
+	Delta       float64
+	Zigzag      float64
+	DeltaZigzag float64
+}
+
+// BytesPerInt returns the average number of bytes each value takes up,
+// including its share of the control bytes.
+func (s *Stats) BytesPerInt() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return float64(s.Size) / float64(s.Count)
+}
+
+// code returns the 2-bit length code of n in the variant, and the number of
+// data bytes it takes.
+func (v Variant) code(n uint32) (byte, int) {
+	if v == Variant0124 {
+		c := code0124(n)
+		return c, int(blens0124[c])
+	}
+	blen := byteLength(n)
+	return blen - 1, int(blen)
+}
+
+// Analyze works out the Stats for encoding src with the given options,
+// without actually encoding it. Along the way it sizes up the other
+// combinations of the Delta and Zigzag options, and recommends the
+// smallest.
+func Analyze(src []uint32, opts Options) *Stats {
+	s := &Stats{Count: len(src)}
+	mode := 0
+	if opts.Delta {
+		mode |= 1
+	}
+	if opts.Zigzag {
+		mode |= 2
+	}
+
+	// The candidate values are indexed by mode: plain, delta, zigzag, and
+	// delta with zigzag, in the same order as ModeCosts.
+	var sizes [4]int
+	var prev uint32
+	var ctrl byte
+	for ix, num := range src {
+		diff := num - prev
+		prev = num
+		cands := [4]uint32{num, diff, zigzag(int32(num)), zigzag(int32(diff))}
+		for m, c := range cands {
+			_, n := opts.Variant.code(c)
+			sizes[m] += n
+		}
+
+		c, _ := opts.Variant.code(cands[mode])
+		s.Lengths[c]++
+		ctrl |= c << opts.Format.shift(uint(ix%4))
+		if ix%4 == 3 || ix == len(src)-1 {
+			s.Control[ctrl]++
+			ctrl = 0
+		}
+	}
+
+	clen := (len(src) + 3) / 4
+	s.Size = clen + sizes[mode]
+	best := 0
+	for m := range sizes {
+		if sizes[m] < sizes[best] {
+			best = m
+		}
+	}
+	s.Recommended = opts
+	s.Recommended.Delta, s.Recommended.Zigzag = best&1 != 0, best&2 != 0
+	if len(src) > 0 {
+		per := func(size int) float64 {
+			return float64(clen+size) / float64(len(src))
+		}
+		s.Modes = ModeCosts{per(sizes[0]), per(sizes[1]), per(sizes[2]), per(sizes[3])}
+	}
+	return s
+}
+
+// AnalyzeEncoded works out the Stats for a stream that has already been
+// encoded in the standard variant, such as by Encode or EncodeDelta, in
+// either format. The number of values is worked out from how many data
+// bytes the trailing partial quad is missing.
+func AnalyzeEncoded(ctrl, data []byte) (*Stats, error) {
+	s := &Stats{Size: len(ctrl) + len(data)}
+	for _, c := range ctrl {
+		s.Control[c]++
+		for i := uint(0); i < 4; i++ {
+			s.Lengths[c>>(2*i)&0x03]++
+		}
+	}
+
+	// The unused slots of a partial quad have code 0, but no data bytes.
+	pad := skipData(ctrl) - len(data)
+	switch {
+	case pad < 0:
+		return nil, ErrTrailingData
+	case pad > 3 || pad > s.Lengths[0]:
+		return nil, ErrShortData
+	}
+	s.Lengths[0] -= pad
+	s.Count = 4*len(ctrl) - pad
+	return s, nil
+}
@@ -0,0 +1,118 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestAnalyzeMatchesEncoding(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for _, opts := range containerOptions() {
+		for _, size := range []int{0, 1, 5, 102} {
+			src := make([]uint32, size)
+			for ix := range src {
+				src[ix] = r.Uint32() >> uint(8*r.Intn(4)+r.Intn(8))
+			}
+
+			s := Analyze(src, opts)
+			_, _, body, err := UnmarshalHeader(Marshal(nil, src, opts))
+			if err != nil {
+				t.Fatalf("%v\n", err)
+			}
+			if s.Count != size || s.Size != len(body) {
+				t.Errorf("%+v %d: %d, %d != %d\n", opts, size, s.Count, s.Size, len(body))
+			}
+
+			var control [256]int
+			for _, c := range body[:(size+3)/4] {
+				control[c]++
+			}
+			if s.Control != control {
+				t.Errorf("%+v %d: control histogram mismatch\n", opts, size)
+			}
+		}
+	}
+}
+
+func TestAnalyzeEncoded(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for _, f := range []Format{Legacy, Reference} {
+		for size := 0; size < 30; size++ {
+			src := make([]uint32, size)
+			for ix := range src {
+				src[ix] = r.Uint32() >> uint(8*r.Intn(4))
+			}
+			encoded := f.Encode(nil, src)
+			clen := (size + 3) / 4
+
+			s, err := AnalyzeEncoded(encoded[:clen], encoded[clen:])
+			if err != nil {
+				t.Errorf("%v %d: %v\n", f, size, err)
+				continue
+			}
+			expected := Analyze(src, Options{Format: f})
+			if s.Count != expected.Count || s.Size != expected.Size || s.Lengths != expected.Lengths || s.Control != expected.Control {
+				t.Errorf("%v %d: %d %d %v != %d %d %v\n", f, size,
+					s.Count, s.Size, s.Lengths, expected.Count, expected.Size, expected.Lengths)
+			}
+		}
+	}
+
+	ctrl, data := []byte{0xff}, make([]byte, 20)
+	if _, err := AnalyzeEncoded(ctrl, data[:17]); err != ErrTrailingData {
+		t.Errorf("%v != %v\n", err, ErrTrailingData)
+	}
+	if _, err := AnalyzeEncoded(ctrl, data[:15]); !errors.Is(err, ErrShortData) {
+		t.Errorf("%v != %v\n", err, ErrShortData)
+	}
+}
+
+func TestAnalyzeRecommends(t *testing.T) {
+	sorted := make([]uint32, 100)
+	signed := make([]uint32, 100)
+	walk := make([]uint32, 100)
+	var w int32 = 1 << 30
+	for ix := range sorted {
+		sorted[ix] = 1<<30 + uint32(3*ix)
+		signed[ix] = uint32(int32(ix%7 - 3))
+		w += int32(ix%5 - 2)
+		walk[ix] = uint32(w)
+	}
+
+	for _, test := range []struct {
+		src           []uint32
+		delta, zigzag bool
+	}{
+		{[]uint32{1, 2, 3, 4}, false, false},
+		{sorted, true, false},
+		{signed, false, true},
+		{walk, true, true},
+	} {
+		s := Analyze(test.src, Options{Checksum: true})
+		rec := s.Recommended
+		if rec.Delta != test.delta || rec.Zigzag != test.zigzag || !rec.Checksum {
+			t.Errorf("%v: %+v\n", test.src[:4], rec)
+		}
+	}
+
+	s := Analyze(sorted, Options{})
+	if s.Modes.Delta != 1.28 || s.Modes.Plain != 4.25 || s.BytesPerInt() != 4.25 {
+		t.Errorf("%+v, %f\n", s.Modes, s.BytesPerInt())
+	}
+}
@@ -0,0 +1,105 @@
+/*
+ * reference_vectors prints the golden vectors in format_test.go, as encoded
+ * by the reference C implementation, https://github.com/lemire/streamvbyte.
+ * Its output replaces referenceVectors, referenceDeltaVectors and
+ * reference0124Vectors, so any difference from the checked-in vectors shows
+ * up in a diff. Build it against a checkout of the library:
+ *
+ *	git clone https://github.com/lemire/streamvbyte && cd streamvbyte
+ *	git checkout v1.0.0
+ *	cmake -B build . && cmake --build build
+ *	cc -I include path/to/svb/testdata/reference_vectors.c build/libstreamvbyte.a -o /tmp/vectors
+ *	/tmp/vectors
+ *
+ * The inputs here must be kept in step with those in format_test.go.
+ */
+#include <inttypes.h>
+#include <stdio.h>
+#include <stdlib.h>
+
+#include "streamvbyte.h"
+#include "streamvbyte_delta.h"
+
+struct vector {
+	const char *comment;
+	const uint32_t *input;
+	uint32_t length;
+	uint32_t prev;
+};
+
+#define VECTOR(comment, prev, ...) \
+	{comment, (const uint32_t[]){__VA_ARGS__}, \
+	 sizeof((uint32_t[]){__VA_ARGS__}) / sizeof(uint32_t), prev}
+
+static const struct vector plain[] = {
+	{"", NULL, 0, 0},
+	VECTOR("Figure 3 from https://arxiv.org/pdf/1709.08990.pdf", 0,
+	       1024, 12, 10, 1073741824),
+	VECTOR("Every length boundary, with a trailing partial quad", 0,
+	       255, 256, 65535, 65536, 16777215, 16777216, 4294967295u),
+	VECTOR("Small values only", 0, 0, 1, 2, 3, 4),
+};
+
+static const struct vector delta[] = {
+	VECTOR("Every length boundary of the differences, from a nonzero prev", 100,
+	       100, 101, 356, 65892, 65892, 16843107, 4294967295u),
+	VECTOR("A first value below prev wraps around", 1000, 5, 6),
+};
+
+static const struct vector v0124[] = {
+	VECTOR("Every length code, with a trailing partial quad", 0,
+	       0, 1, 255, 256, 65535, 65536, 4294967295u, 0, 7),
+	VECTOR("Zeros only take up control bytes", 0, 0, 0, 0, 0, 0),
+};
+
+enum kind { PLAIN, DELTA, V0124 };
+
+static void print_vector(const struct vector *v, enum kind kind) {
+	uint8_t *out = malloc(streamvbyte_max_compressedbytes(v->length) + 1);
+	size_t n;
+	switch (kind) {
+	case DELTA:
+		n = streamvbyte_delta_encode(v->input, v->length, out, v->prev);
+		break;
+	case V0124:
+		n = streamvbyte_encode_0124(v->input, v->length, out);
+		break;
+	default:
+		n = streamvbyte_encode(v->input, v->length, out);
+	}
+
+	if (*v->comment != '\0') {
+		printf("\t{ // %s\n", v->comment);
+	} else {
+		printf("\t{\n");
+	}
+	printf("\t\t[]uint32{");
+	for (uint32_t i = 0; i < v->length; i++) {
+		printf(i ? ", %" PRIu32 : "%" PRIu32, v->input[i]);
+	}
+	printf("},\n");
+	if (kind == DELTA) {
+		printf("\t\t%" PRIu32 ",\n", v->prev);
+	}
+	printf("\t\t[]byte{");
+	for (size_t i = 0; i < n; i++) {
+		printf(i ? ", 0x%02x" : "0x%02x", out[i]);
+	}
+	printf("},\n\t},\n");
+	free(out);
+}
+
+static void print_table(const char *name, const struct vector *vs, size_t len, enum kind kind) {
+	printf("%s:\n", name);
+	for (size_t i = 0; i < len; i++) {
+		print_vector(&vs[i], kind);
+	}
+	printf("\n");
+}
+
+int main(void) {
+	print_table("referenceVectors", plain, sizeof(plain) / sizeof(plain[0]), PLAIN);
+	print_table("referenceDeltaVectors", delta, sizeof(delta) / sizeof(delta[0]), DELTA);
+	print_table("reference0124Vectors", v0124, sizeof(v0124) / sizeof(v0124[0]), V0124);
+	return 0;
+}
@@ -0,0 +1,179 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import "math/bits"
+
+// The 16-bit variant only needs to tell 1 byte values apart from 2 byte
+// ones, so each value gets a single control bit, and a control byte covers
+// a block of 8 values rather than a quad (first value in the highest bit).
+// Data bytes are written big-endian.
+
+// ctrlLen16 is the size of the control section for count 16-bit values.
+func ctrlLen16(count int) int {
+	return (count + 7) / 8
+}
+
+// PutU16Block encodes a single block of 8 uint16 values, and is the 16-bit
+// counterpart to PutU32Block.
+//
+// The data parameter is the buffer where the encoded values are written, and
+// may need up to 16 bytes available. The block parameter needs to have 8
+// values available. The diff value works just like it does in PutU32Block,
+// with the differences taken across the block of 8.
+//
+// The ctrl byte returned is a required hint for decoding, and the return
+// value n represents the number of bytes used in the data buffer.
+//
+// Panics will be thrown if there are too few bytes available in the data
+// buffer, or too few values in the block buffer.
+func PutU16Block(data []byte, block []uint16, diff bool) (ctrl byte, n int) {
+	var prev uint16
+	for i := uint(0); i < 8; i++ {
+		num := block[i]
+		if diff {
+			num, prev = num-prev, num
+		}
+		if num > 0xff {
+			ctrl |= 1 << (7 - i)
+			data[n] = byte(num >> 8)
+			n++
+		}
+		data[n] = byte(num)
+		n++
+	}
+	return ctrl, n
+}
+
+// GetU16Block decodes a single block of 8 uint16 values, and is the
+// read-side parallel to PutU16Block.
+//
+// Panics will be thrown if there are too few bytes available in the data
+// buffer.
+func GetU16Block(ctrl byte, data []byte, diff bool) (block [8]uint16, n int) {
+	var prev uint16
+	for i := uint(0); i < 8; i++ {
+		var num uint16
+		if ctrl&(1<<(7-i)) != 0 {
+			num = uint16(data[n]) << 8
+			n++
+		}
+		num |= uint16(data[n])
+		n++
+		if diff {
+			num += prev
+			prev = num
+		}
+		block[i] = num
+	}
+	return block, n
+}
+
+// GetU16BlockChecked is like GetU16Block, except that it returns a
+// *DecodeError wrapping ErrShortData rather than panicking when there are
+// too few bytes available in the data buffer.
+func GetU16BlockChecked(ctrl byte, data []byte, diff bool) (block [8]uint16, n int, err error) {
+	if len(data) < 8+bits.OnesCount8(ctrl) {
+		return block, 0, shortData(0, len(data), 8, func(ix int) int {
+			return 1 + int(ctrl>>(7-uint(ix))&1)
+		})
+	}
+	block, n = GetU16Block(ctrl, data, diff)
+	return block, n, nil
+}
+
+// Encode16 is the 16-bit counterpart to Encode. The control section takes 1
+// byte for every block of 8 values.
+func Encode16(dst []byte, src []uint16) []byte {
+	clen := ctrlLen16(len(src))
+	if max := clen + 2*len(src); cap(dst) < max {
+		dst = make([]byte, max)
+	} else {
+		dst = dst[:max]
+	}
+	ctrl, data := dst[:clen], dst[clen:]
+
+	var n int
+	var block [8]uint16
+	var scratch [16]byte
+	for ix := 0; ix < len(src); ix += 8 {
+		k := copy(block[:], src[ix:])
+		var c byte
+		var size int
+		if k == 8 {
+			c, size = PutU16Block(data[n:], block[:], false)
+		} else {
+			// Same as putPartial: the zero padding is written last.
+			for jx := k; jx < 8; jx++ {
+				block[jx] = 0
+			}
+			c, size = PutU16Block(scratch[:], block[:], false)
+			size -= 8 - k
+			copy(data[n:], scratch[:size])
+		}
+		ctrl[ix/8] = c
+		n += size
+	}
+	return dst[:clen+n]
+}
+
+// Decode16 is the 16-bit counterpart to Decode.
+func Decode16(dst []uint16, src []byte, count int) ([]uint16, error) {
+	if count < 0 {
+		return nil, ErrInvalidCount
+	}
+	// Checking the count against src first keeps a huge count from
+	// overflowing the control length.
+	if count > 8*len(src) {
+		return nil, &DecodeError{Err: ErrShortControl, Index: 8 * len(src), Offset: len(src)}
+	}
+	clen := ctrlLen16(count)
+	if len(src) < clen {
+		return nil, &DecodeError{Err: ErrShortControl, Index: 8 * len(src), Offset: len(src)}
+	}
+	if cap(dst) < count {
+		dst = make([]uint16, count)
+	} else {
+		dst = dst[:count]
+	}
+	ctrl, data := src[:clen], src[clen:]
+
+	var n int
+	for ix := 0; ix < count; ix += 8 {
+		c := ctrl[ix/8]
+		k := count - ix
+		if k > 8 {
+			k = 8
+		}
+		// Only the control bits of the k values that are present count.
+		need := k + bits.OnesCount8(c&^(0xff>>uint(k)))
+		if len(data)-n < need {
+			return nil, shortData(clen, len(data), count, func(ix int) int {
+				return 1 + int(ctrl[ix/8]>>(7-uint(ix%8))&1)
+			})
+		}
+		var block [8]uint16
+		if k == 8 {
+			block, _ = GetU16Block(c, data[n:], false)
+		} else {
+			var scratch [16]byte
+			copy(scratch[:], data[n:n+need])
+			block, _ = GetU16Block(c, scratch[:], false)
+		}
+		copy(dst[ix:], block[:k])
+		n += need
+	}
+	return dst, nil
+}
@@ -0,0 +1,181 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestU16Block(t *testing.T) {
+	tests := []struct {
+		block []uint16
+		ctrl  byte
+		data  []byte
+	}{
+		{ // Smallest possible encoded
+			[]uint16{0, 0, 0, 0, 0, 0, 0, 0},
+			0x00,
+			[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		},
+		{ // A mix of 1 and 2 bytes
+			[]uint16{0xff, 0x100, 7, 0xffff, 0, 0x1234, 0x80, 0x8000},
+			0x55, // 0 1 0 1 0 1 0 1
+			[]byte{
+				0xff,
+				0x01, 0x00,
+				0x07,
+				0xff, 0xff,
+				0x00,
+				0x12, 0x34,
+				0x80,
+				0x80, 0x00,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		data := make([]byte, 16)
+		ctrl, size := PutU16Block(data, test.block, false)
+		if ctrl != test.ctrl {
+			t.Errorf("ctrl mismatch: %#x != %#x\n", ctrl, test.ctrl)
+		}
+		if !bytes.Equal(data[:size], test.data) {
+			t.Errorf("data mismatch: % x != % x\n", data[:size], test.data)
+		}
+
+		block, n := GetU16Block(ctrl, test.data, false)
+		if n != len(test.data) {
+			t.Errorf("size mismatch: %d != %d\n", n, len(test.data))
+		}
+		for ix := range test.block {
+			if block[ix] != test.block[ix] {
+				t.Errorf("mismatch: %v != %v\n", block, test.block)
+				break
+			}
+		}
+
+		if _, _, err := GetU16BlockChecked(ctrl, test.data[:len(test.data)-1], false); !errors.Is(err, ErrShortData) {
+			t.Errorf("%v != %v\n", err, ErrShortData)
+		}
+	}
+}
+
+func TestU16BlockDiff(t *testing.T) {
+	block := []uint16{1000, 1001, 1300, 1301, 1302, 2000, 2100, 2101}
+	data := make([]byte, 16)
+	ctrl, size := PutU16Block(data, block, true)
+	if ctrl != 0xa4 || size != 11 {
+		t.Errorf("mismatch: %#x, %d\n", ctrl, size)
+	}
+	b, n := GetU16Block(ctrl, data, true)
+	if n != size {
+		t.Errorf("size mismatch: %d != %d\n", n, size)
+	}
+	for ix := range block {
+		if b[ix] != block[ix] {
+			t.Errorf("mismatch: %v != %v\n", b, block)
+			break
+		}
+	}
+}
+
+func TestEncode16Roundtrip(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for count := 0; count < 50; count++ {
+		src := make([]uint16, count)
+		for ix := range src {
+			src[ix] = uint16(r.Uint32()) >> uint(8*r.Intn(2))
+		}
+
+		encoded := Encode16(nil, src)
+		vals, err := Decode16(nil, encoded, count)
+		if err != nil {
+			t.Errorf("unexpected: %v\n", err)
+			continue
+		}
+		for ix := range src {
+			if vals[ix] != src[ix] {
+				t.Errorf("mismatch: %v != %v\n", vals, src)
+				break
+			}
+		}
+
+		if count > 0 {
+			if _, err := Decode16(nil, encoded[:len(encoded)-1], count); !errors.Is(err, ErrShortData) {
+				t.Errorf("%v != %v\n", err, ErrShortData)
+			}
+		}
+	}
+
+	if _, err := Decode16(nil, []byte{0x00}, 9); !errors.Is(err, ErrShortControl) {
+		t.Errorf("%v != %v\n", err, ErrShortControl)
+	}
+}
+
+func TestStream16Roundtrip(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	src := make([]uint16, 1001)
+	for ix := range src {
+		src[ix] = uint16(r.Uint32()) >> uint(8*r.Intn(2))
+	}
+
+	var buf bytes.Buffer
+	w := NewWriter16Size(&buf, 100)
+	w.WriteUint16s(src)
+	w.Close()
+
+	var vals []uint16
+	rd := NewReader16(&buf)
+	dst := make([]uint16, 77)
+	for {
+		n, err := rd.ReadUint16s(dst)
+		vals = append(vals, dst[:n]...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected: %v\n", err)
+		}
+	}
+
+	if len(vals) != len(src) {
+		t.Fatalf("len: %d != %d\n", len(vals), len(src))
+	}
+	for ix := range src {
+		if vals[ix] != src[ix] {
+			t.Fatalf("%d: %d != %d\n", ix, vals[ix], src[ix])
+		}
+	}
+}
+
+func TestWriter16Write(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter16(&buf)
+	w.Write([]byte{0x34})
+	w.Write([]byte{0x12, 0xff})
+	if err := w.Close(); err != ErrPartialValue {
+		t.Errorf("%v != %v\n", err, ErrPartialValue)
+	}
+
+	v, err := NewReader16(&buf).ReadUint16()
+	if err != nil || v != 0x1234 {
+		t.Errorf("%#x, %v\n", v, err)
+	}
+}
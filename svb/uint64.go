@@ -0,0 +1,195 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import "math/bits"
+
+// The 64-bit variant works just like the 32-bit one, except that each value
+// takes between 1 and 8 data bytes, so it needs a 3-bit length code. The
+// four codes of a quad are packed into the low 12 bits of a uint16 control
+// word (first value in the highest bits), which is stored as 2 big-endian
+// bytes in the control section. Data bytes are written big-endian.
+
+func byteLength64(n uint64) uint8 {
+	if n == 0 {
+		return 1
+	}
+	return uint8(8 - bits.LeadingZeros64(n)/8)
+}
+
+// ctrlLen64 is the size of the control section for count 64-bit values.
+func ctrlLen64(count int) int {
+	return 2 * ((count + 3) / 4)
+}
+
+// PutU64Block encodes a single quad of uint64 values, and is the 64-bit
+// counterpart to PutU32Block.
+//
+// The data parameter is the buffer where the encoded values are written, and
+// may need up to 32 bytes available. The quad parameter needs to have 4
+// values available. The diff value works just like it does in PutU32Block.
+//
+// The ctrl word returned is a required hint for decoding, and the return
+// value n represents the number of bytes used in the data buffer.
+//
+// Panics will be thrown if there are too few bytes available in the data
+// buffer, or too few values in the quad buffer.
+func PutU64Block(data []byte, quad []uint64, diff bool) (ctrl uint16, n int) {
+	var prev uint64
+	for i := uint(0); i < 4; i++ {
+		num := quad[i]
+		if diff {
+			num = num - prev
+			prev += num
+		}
+		blen := byteLength64(num)
+		ctrl |= uint16(blen-1) << (9 - 3*i)
+		for shift := 8 * uint(blen-1); shift > 0; shift -= 8 {
+			data[n] = byte(num >> shift)
+			n++
+		}
+		data[n] = byte(num)
+		n++
+	}
+	return ctrl, n
+}
+
+// lengths64 unpacks the four byte lengths held in a 64-bit control word.
+func lengths64(ctrl uint16) (blens [4]uint8) {
+	for i := uint(0); i < 4; i++ {
+		blens[i] = uint8((ctrl>>(9-3*i))&0x07) + 1
+	}
+	return blens
+}
+
+// GetU64Block decodes a single quad of uint64 values, and is the read-side
+// parallel to PutU64Block.
+//
+// Panics will be thrown if there are too few bytes available in the data
+// buffer.
+func GetU64Block(ctrl uint16, data []byte, diff bool) (quad [4]uint64, n int) {
+	for ix, blen := range lengths64(ctrl) {
+		var num uint64
+		for jx := uint8(0); jx < blen; jx++ {
+			num = num<<8 | uint64(data[n])
+			n++
+		}
+		quad[ix] = num
+	}
+	if diff {
+		quad[1] += quad[0]
+		quad[2] += quad[1]
+		quad[3] += quad[2]
+	}
+	return quad, n
+}
+
+// GetU64BlockChecked is like GetU64Block, except that it returns a
+// *DecodeError wrapping ErrShortData rather than panicking when there are
+// too few bytes available in the data buffer.
+func GetU64BlockChecked(ctrl uint16, data []byte, diff bool) (quad [4]uint64, n int, err error) {
+	blens := lengths64(ctrl)
+	if len(data) < int(blens[0]+blens[1]+blens[2]+blens[3]) {
+		return quad, 0, shortData(0, len(data), 4, func(ix int) int {
+			return int(blens[ix])
+		})
+	}
+	quad, n = GetU64Block(ctrl, data, diff)
+	return quad, n, nil
+}
+
+// Encode64 is the 64-bit counterpart to Encode. The control section takes 2
+// bytes for every quad of values.
+func Encode64(dst []byte, src []uint64) []byte {
+	clen := ctrlLen64(len(src))
+	if max := clen + 8*len(src); cap(dst) < max {
+		dst = make([]byte, max)
+	} else {
+		dst = dst[:max]
+	}
+	ctrl, data := dst[:clen], dst[clen:]
+
+	var n int
+	var quad [4]uint64
+	var scratch [32]byte
+	for ix := 0; ix < len(src); ix += 4 {
+		k := copy(quad[:], src[ix:])
+		var c uint16
+		var size int
+		if k == 4 {
+			c, size = PutU64Block(data[n:], quad[:], false)
+		} else {
+			// Same as putPartial: the zero padding is written last.
+			for jx := k; jx < 4; jx++ {
+				quad[jx] = 0
+			}
+			c, size = PutU64Block(scratch[:], quad[:], false)
+			size -= 4 - k
+			copy(data[n:], scratch[:size])
+		}
+		ctrl[ix/2] = byte(c >> 8)
+		ctrl[ix/2+1] = byte(c)
+		n += size
+	}
+	return dst[:clen+n]
+}
+
+// Decode64 is the 64-bit counterpart to Decode.
+func Decode64(dst []uint64, src []byte, count int) ([]uint64, error) {
+	if count < 0 {
+		return nil, ErrInvalidCount
+	}
+	if count > 4*(len(src)/2) {
+		return nil, &DecodeError{Err: ErrShortControl, Index: 4 * (len(src) / 2), Offset: len(src) &^ 1}
+	}
+	clen := ctrlLen64(count)
+	if cap(dst) < count {
+		dst = make([]uint64, count)
+	} else {
+		dst = dst[:count]
+	}
+	ctrl, data := src[:clen], src[clen:]
+
+	var n int
+	for ix := 0; ix < count; ix += 4 {
+		c := uint16(ctrl[ix/2])<<8 | uint16(ctrl[ix/2+1])
+		k := count - ix
+		if k > 4 {
+			k = 4
+		}
+		blens := lengths64(c)
+		var need int
+		for _, blen := range blens[:k] {
+			need += int(blen)
+		}
+		if len(data)-n < need {
+			return nil, shortData(clen, len(data), count, func(ix int) int {
+				c := uint16(ctrl[ix/4*2])<<8 | uint16(ctrl[ix/4*2+1])
+				return int(lengths64(c)[ix%4])
+			})
+		}
+		var quad [4]uint64
+		if k == 4 {
+			quad, _ = GetU64Block(c, data[n:], false)
+		} else {
+			var scratch [32]byte
+			copy(scratch[:], data[n:n+need])
+			quad, _ = GetU64Block(c, scratch[:], false)
+		}
+		copy(dst[ix:], quad[:k])
+		n += need
+	}
+	return dst, nil
+}
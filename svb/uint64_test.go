@@ -0,0 +1,154 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestU64Block(t *testing.T) {
+	tests := []struct {
+		quad []uint64
+		ctrl uint16
+		data []byte
+	}{
+		{ // Smallest possible encoded
+			[]uint64{0, 0, 0, 0},
+			0x000,
+			[]byte{0x00, 0x00, 0x00, 0x00},
+		},
+		{ // 1, 5, 8 and 2 bytes
+			[]uint64{0xff, 1 << 32, 1 << 63, 0x1234},
+			0x139, // 000 | 100 | 111 | 001
+			[]byte{
+				0xff,
+				0x01, 0x00, 0x00, 0x00, 0x00,
+				0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+				0x12, 0x34,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		data := make([]byte, 32)
+		ctrl, size := PutU64Block(data, test.quad, false)
+		if ctrl != test.ctrl {
+			t.Errorf("ctrl mismatch: %#x != %#x\n", ctrl, test.ctrl)
+		}
+		if !bytes.Equal(data[:size], test.data) {
+			t.Errorf("data mismatch: % x != % x\n", data[:size], test.data)
+		}
+
+		quad, n := GetU64Block(ctrl, test.data, false)
+		if n != len(test.data) {
+			t.Errorf("size mismatch: %d != %d\n", n, len(test.data))
+		}
+		for ix := range test.quad {
+			if quad[ix] != test.quad[ix] {
+				t.Errorf("mismatch: %v != %v\n", quad, test.quad)
+				break
+			}
+		}
+	}
+}
+
+func TestU64BlockDiff(t *testing.T) {
+	quad := []uint64{1 << 40, 1<<40 + 1, 1<<40 + 300, 1<<40 + 70000}
+	data := make([]byte, 32)
+	ctrl, size := PutU64Block(data, quad, true)
+	if size != 6+1+2+3 {
+		t.Errorf("size mismatch: %d\n", size)
+	}
+	q, n := GetU64Block(ctrl, data, true)
+	if n != size {
+		t.Errorf("size mismatch: %d != %d\n", n, size)
+	}
+	for ix := range quad {
+		if q[ix] != quad[ix] {
+			t.Errorf("mismatch: %v != %v\n", q, quad)
+			break
+		}
+	}
+}
+
+func TestEncode64Roundtrip(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for count := 0; count < 50; count++ {
+		src := make([]uint64, count)
+		for ix := range src {
+			src[ix] = uint64(r.Int63()) >> uint(8*r.Intn(8))
+		}
+
+		encoded := Encode64(nil, src)
+		vals, err := Decode64(nil, encoded, count)
+		if err != nil {
+			t.Errorf("unexpected: %v\n", err)
+			continue
+		}
+		for ix := range src {
+			if vals[ix] != src[ix] {
+				t.Errorf("mismatch: %v != %v\n", vals, src)
+				break
+			}
+		}
+
+		if count > 0 {
+			if _, err := Decode64(nil, encoded[:len(encoded)-1], count); !errors.Is(err, ErrShortData) {
+				t.Errorf("%v != %v\n", err, ErrShortData)
+			}
+		}
+	}
+}
+
+func TestStream64Roundtrip(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	src := make([]uint64, 1001)
+	for ix := range src {
+		src[ix] = uint64(r.Int63()) >> uint(8*r.Intn(8))
+	}
+
+	var buf bytes.Buffer
+	w := NewWriter64Size(&buf, 100)
+	w.WriteUint64s(src)
+	w.Close()
+
+	var vals []uint64
+	rd := NewReader64(&buf)
+	dst := make([]uint64, 77)
+	for {
+		n, err := rd.ReadUint64s(dst)
+		vals = append(vals, dst[:n]...)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected: %v\n", err)
+		}
+	}
+
+	if len(vals) != len(src) {
+		t.Fatalf("len: %d != %d\n", len(vals), len(src))
+	}
+	for ix := range src {
+		if vals[ix] != src[ix] {
+			t.Fatalf("%d: %d != %d\n", ix, vals[ix], src[ix])
+		}
+	}
+}
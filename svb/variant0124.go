@@ -0,0 +1,211 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+// The "0124" variant uses the 2-bit codes of the control byte to mean 0, 1,
+// 2 or 4 data bytes, rather than 1, 2, 3 or 4. A zero value costs no data
+// bytes at all, which suits data that is mostly zeros, at the price of
+// spending 4 bytes on values that would otherwise have needed 3.
+
+// blens0124 maps a 2-bit code to its data byte length.
+var blens0124 = [4]uint8{0, 1, 2, 4}
+
+// lookup0124 and refLookup0124 are the 0124 counterparts to lookup and
+// refLookup.
+var lookup0124, refLookup0124 [256][4]uint8
+
+func init() {
+	for c := 0; c < 256; c++ {
+		ctrl := byte(c)
+		var blens [4]uint8
+		for i := uint(0); i < 4; i++ {
+			blens[i] = blens0124[(ctrl>>Legacy.shift(i))&0x03]
+		}
+		lookup0124[ctrl] = blens
+		refLookup0124[ctrl] = [4]uint8{blens[3], blens[2], blens[1], blens[0]}
+	}
+}
+
+// table0124 returns the 0124 control byte lookup table for the format.
+func (f Format) table0124() *[256][4]uint8 {
+	if f == Reference {
+		return &refLookup0124
+	}
+	return &lookup0124
+}
+
+func code0124(n uint32) byte {
+	if n == 0 {
+		return 0
+	}
+	if n < 256 {
+		return 1
+	}
+	if n < 65536 {
+		return 2
+	}
+	return 3
+}
+
+// PutU32Block0124 is the 0124 variant of PutU32Block. Zero values (or zero
+// differences, when diff is set) are recorded in the control byte only.
+//
+// Panics will be thrown if there are too few bytes available in the data
+// buffer, or too few values in the quad buffer.
+func PutU32Block0124(data []byte, quad []uint32, diff bool) (ctrl byte, n int) {
+	return Legacy.PutU32Block0124(data, quad, diff)
+}
+
+// PutU32Block0124 is the Format-specific version of the package-level
+// PutU32Block0124.
+func (f Format) PutU32Block0124(data []byte, quad []uint32, diff bool) (ctrl byte, n int) {
+	var prev uint32
+	for i := uint(0); i < 4; i++ {
+		num := quad[i]
+		if diff {
+			num = num - prev
+			prev += num
+		}
+		code := code0124(num)
+		ctrl |= code << f.shift(i)
+		if code == 0 {
+			continue
+		}
+		if f == Reference {
+			n += putLittle(data[n:], num, blens0124[code])
+		} else {
+			n += putBig(data[n:], num, blens0124[code])
+		}
+	}
+	return ctrl, n
+}
+
+// GetU32Block0124 is the 0124 variant of GetU32Block.
+//
+// Panics will be thrown if there are too few bytes available in the data
+// buffer.
+func GetU32Block0124(ctrl byte, data []byte, diff bool) (quad [4]uint32, n int) {
+	return Legacy.GetU32Block0124(ctrl, data, diff)
+}
+
+// GetU32Block0124Checked is like GetU32Block0124, except that it returns a
+// *DecodeError wrapping ErrShortData rather than panicking when there are
+// too few bytes available in the data buffer.
+func GetU32Block0124Checked(ctrl byte, data []byte, diff bool) (quad [4]uint32, n int, err error) {
+	return Legacy.GetU32Block0124Checked(ctrl, data, diff)
+}
+
+// GetU32Block0124Checked is the Format-specific version of the
+// package-level GetU32Block0124Checked.
+func (f Format) GetU32Block0124Checked(ctrl byte, data []byte, diff bool) (quad [4]uint32, n int, err error) {
+	blens := f.table0124()[ctrl]
+	if len(data) < int(blens[0]+blens[1]+blens[2]+blens[3]) {
+		return quad, 0, shortData(0, len(data), 4, func(ix int) int {
+			return int(blens[ix])
+		})
+	}
+	quad, n = f.GetU32Block0124(ctrl, data, diff)
+	return quad, n, nil
+}
+
+// GetU32Block0124 is the Format-specific version of the package-level
+// GetU32Block0124.
+func (f Format) GetU32Block0124(ctrl byte, data []byte, diff bool) (quad [4]uint32, n int) {
+	blens := f.table0124()[ctrl]
+	for ix, blen := range blens {
+		if blen == 0 {
+			continue
+		}
+		if f == Reference {
+			quad[ix], n = getLittle(data, n, blen)
+		} else {
+			quad[ix], n = getBig(data, n, blen)
+		}
+	}
+	if diff {
+		quad[1] += quad[0]
+		quad[2] += quad[1]
+		quad[3] += quad[2]
+	}
+	return quad, n
+}
+
+// Encode0124 is the 0124 variant of Encode. Since zeros take no data bytes,
+// the unused slots of a trailing partial quad cost nothing.
+func Encode0124(dst []byte, src []uint32) []byte {
+	return Legacy.Encode0124(dst, src)
+}
+
+// Encode0124 is the Format-specific version of the package-level
+// Encode0124.
+func (f Format) Encode0124(dst []byte, src []uint32) []byte {
+	return Options{Format: f, Variant: Variant0124}.encode(dst, src, 0)
+}
+
+// Decode0124 is the 0124 variant of Decode.
+func Decode0124(dst []uint32, src []byte, count int) ([]uint32, error) {
+	return Legacy.Decode0124(dst, src, count)
+}
+
+// Decode0124 is the Format-specific version of the package-level
+// Decode0124.
+func (f Format) Decode0124(dst []uint32, src []byte, count int) ([]uint32, error) {
+	if count < 0 {
+		return nil, ErrInvalidCount
+	}
+	// Checking the count against src first keeps a huge count from
+	// overflowing the control length.
+	if count > 4*len(src) {
+		return nil, &DecodeError{Err: ErrShortControl, Index: 4 * len(src), Offset: len(src)}
+	}
+	clen := (count + 3) / 4
+	if cap(dst) < count {
+		dst = make([]uint32, count)
+	} else {
+		dst = dst[:count]
+	}
+	ctrl, data := src[:clen], src[clen:]
+	table := f.table0124()
+
+	var n int
+	for ix := 0; ix < count; ix += 4 {
+		c := ctrl[ix/4]
+		blens := table[c]
+		k := count - ix
+		if k > 4 {
+			k = 4
+		}
+		var need int
+		for _, blen := range blens[:k] {
+			need += int(blen)
+		}
+		if len(data)-n < need {
+			return nil, shortData(clen, len(data), count, func(ix int) int {
+				return int(table[ctrl[ix/4]][ix%4])
+			})
+		}
+		var quad [4]uint32
+		if k == 4 {
+			quad, _ = f.GetU32Block0124(c, data[n:], false)
+		} else {
+			var scratch [16]byte
+			copy(scratch[:], data[n:n+need])
+			quad, _ = f.GetU32Block0124(c, scratch[:], false)
+		}
+		copy(dst[ix:], quad[:k])
+		n += need
+	}
+	return dst, nil
+}
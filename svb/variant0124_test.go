@@ -0,0 +1,134 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestLengths0124(t *testing.T) {
+	for key, vals := range lookup0124 {
+		for ix, shift := range []uint{6, 4, 2, 0} {
+			expected := blens0124[(key>>shift)&0x03]
+			if vals[ix] != expected {
+				t.Errorf("%#x, %d: %d != %d\n", key, ix, expected, vals[ix])
+			}
+		}
+	}
+}
+
+func TestEncode0124(t *testing.T) {
+	tests := []struct {
+		format Format
+		input  []uint32
+		data   []byte
+	}{
+		{ // All zeros take no data bytes
+			Legacy,
+			[]uint32{0, 0, 0, 0, 0},
+			[]byte{0x00, 0x00},
+		},
+		{
+			Legacy,
+			[]uint32{0, 12, 1024, 16777216, 0, 7},
+			[]byte{
+				0x1b,       // 00 | 01 | 10 | 11
+				0x10,       // 00 | 01 | 00 | 00
+				0x0c,       // 12
+				0x04, 0x00, // 1024
+				0x01, 0x00, 0x00, 0x00, // 16,777,216
+				0x07, // 7
+			},
+		},
+		{
+			Reference,
+			[]uint32{0, 12, 1024, 16777216, 0, 7},
+			[]byte{
+				0xe4,       // 11 | 10 | 01 | 00
+				0x04,       // 00 | 00 | 01 | 00
+				0x0c,       // 12
+				0x00, 0x04, // 1024
+				0x00, 0x00, 0x00, 0x01, // 16,777,216
+				0x07, // 7
+			},
+		},
+	}
+
+	for _, test := range tests {
+		out := test.format.Encode0124(nil, test.input)
+		if !bytes.Equal(out, test.data) {
+			t.Errorf("%v: % x != % x\n", test.format, out, test.data)
+		}
+
+		vals, err := test.format.Decode0124(nil, out, len(test.input))
+		if err != nil {
+			t.Errorf("%v: unexpected: %v\n", test.format, err)
+			continue
+		}
+		for ix := range test.input {
+			if vals[ix] != test.input[ix] {
+				t.Errorf("%v: %d != %d\n", test.format, vals[ix], test.input[ix])
+			}
+		}
+	}
+}
+
+func TestU32Block0124Diff(t *testing.T) {
+	quad := []uint32{5, 5, 5, 70000}
+	data := make([]byte, 16)
+	ctrl, size := PutU32Block0124(data, quad, true)
+	if ctrl != 0x43 || size != 5 {
+		t.Errorf("%#x, %d != 0x43, 5\n", ctrl, size)
+	}
+	q, n := GetU32Block0124(ctrl, data, true)
+	if n != size {
+		t.Errorf("size mismatch: %d != %d\n", n, size)
+	}
+	for ix := range quad {
+		if q[ix] != quad[ix] {
+			t.Errorf("mismatch: %v != %v\n", q, quad)
+			break
+		}
+	}
+}
+
+func TestRoundtrip0124(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for _, f := range []Format{Legacy, Reference} {
+		for count := 0; count < 50; count++ {
+			src := make([]uint32, count)
+			for ix := range src {
+				if r.Intn(2) == 0 {
+					src[ix] = r.Uint32() >> uint(8*r.Intn(4))
+				}
+			}
+
+			vals, err := f.Decode0124(nil, f.Encode0124(nil, src), count)
+			if err != nil {
+				t.Errorf("%v: unexpected: %v\n", f, err)
+				continue
+			}
+			for ix := range src {
+				if vals[ix] != src[ix] {
+					t.Errorf("%v: mismatch: %v != %v\n", f, vals, src)
+					break
+				}
+			}
+		}
+	}
+}
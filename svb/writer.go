@@ -0,0 +1,263 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// DefaultChunkSize is the number of values a Writer buffers before writing
+// a chunk, unless another size is given to NewWriterSize.
+const DefaultChunkSize = 4096
+
+var (
+	// ErrPartialValue is returned by the Close method of the writers when
+	// the bytes given to Write did not add up to a whole number of values.
+	ErrPartialValue = errors.New("svb: partial value written")
+
+	errWriterClosed = errors.New("svb: write to closed Writer")
+)
+
+// Writer encodes a stream of uint32 values, written a few at a time, into a
+// sequence of self-describing chunks. Each chunk is the uvarint count of
+// values, the uvarint length of the data section, and then the control and
+// data bytes of those values in the layout produced by Encode. The chunks
+// don't record their Format, so a Reader has to be given the same one.
+//
+// Values are buffered until a full chunk is available, or until Flush or
+// Close is called. Close does not close the underlying io.Writer.
+type Writer struct {
+	chunkWriter
+	format Format
+
+	// The values that are not yet part of a whole quad.
+	quad    [4]uint32
+	pending int
+}
+
+// NewWriter returns a Writer that writes chunks of DefaultChunkSize values
+// to w.
+func NewWriter(w io.Writer) *Writer {
+	return Legacy.NewWriter(w)
+}
+
+// NewWriter is the Format-specific version of the package-level NewWriter.
+func (f Format) NewWriter(w io.Writer) *Writer {
+	return f.NewWriterSize(w, DefaultChunkSize)
+}
+
+// NewWriterSize returns a Writer that writes chunks of (at most) size values
+// to w. The size is rounded up to a multiple of 4.
+func NewWriterSize(w io.Writer, size int) *Writer {
+	return Legacy.NewWriterSize(w, size)
+}
+
+// NewWriterSize is the Format-specific version of the package-level
+// NewWriterSize.
+func (f Format) NewWriterSize(w io.Writer, size int) *Writer {
+	size = chunkSize(size, 4)
+	return &Writer{
+		chunkWriter: newChunkWriter(w, size, size/4, 4*size),
+		format:      f,
+	}
+}
+
+// WriteUint32 adds a single value to the stream.
+func (z *Writer) WriteUint32(v uint32) error {
+	if z.err != nil {
+		return z.err
+	}
+	z.quad[z.pending] = v
+	z.pending++
+	if z.pending < 4 {
+		return nil
+	}
+
+	z.pending = 0
+	ctrl, n := z.format.PutU32Block(z.data[z.n:], z.quad[:], false)
+	z.ctrl[z.nctrl] = ctrl
+	return z.added(1, n, 4)
+}
+
+// WriteUint32s adds all of vs to the stream.
+func (z *Writer) WriteUint32s(vs []uint32) error {
+	for _, v := range vs {
+		if err := z.WriteUint32(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Write implements io.Writer, treating p as a sequence of little-endian
+// uint32 values. A value may be split across calls to Write.
+func (z *Writer) Write(p []byte) (int, error) {
+	if z.err != nil {
+		return 0, z.err
+	}
+	return z.split(p, 4, func(b []byte) error {
+		return z.WriteUint32(binary.LittleEndian.Uint32(b))
+	})
+}
+
+// Flush writes any buffered values, including a trailing partial quad, to
+// the underlying io.Writer as a chunk.
+func (z *Writer) Flush() error {
+	if z.err != nil {
+		return z.err
+	}
+	if z.pending > 0 {
+		ctrl, n := z.format.putPartial(z.data[z.n:], z.quad[:z.pending])
+		z.ctrl[z.nctrl] = ctrl
+		z.nctrl++
+		z.n += n
+		z.count += z.pending
+		z.pending = 0
+	}
+	return z.emit()
+}
+
+// Close flushes any buffered values. Further writes will return an error.
+// If the bytes given to Write did not end on a value boundary, Close returns
+// ErrPartialValue.
+func (z *Writer) Close() error {
+	return z.close(z.Flush)
+}
+
+// chunkSize rounds a requested chunk size up to a whole number of blocks
+// (of width values).
+func chunkSize(size, width int) int {
+	if size < width {
+		size = width
+	}
+	return (size + width - 1) / width * width
+}
+
+// chunkWriter is the part of Writer, Writer16 and Writer64 that doesn't
+// depend on the width of the values: the chunk being built, and writing it
+// out. Each of them encodes whole blocks straight into ctrl and data, and
+// calls added to account for them.
+type chunkWriter struct {
+	valueSplitter
+	w    io.Writer
+	size int
+	err  error
+
+	// The encoded blocks of the chunk that is being built.
+	ctrl  []byte
+	data  []byte
+	nctrl int
+	n     int
+	count int
+}
+
+func newChunkWriter(w io.Writer, size, clen, dlen int) chunkWriter {
+	return chunkWriter{
+		w:    w,
+		size: size,
+		ctrl: make([]byte, clen),
+		data: make([]byte, dlen),
+	}
+}
+
+// added accounts for a block of count values, with nctrl control bytes and
+// n data bytes, that has just been encoded. Once the chunk is full, it is
+// written out.
+func (c *chunkWriter) added(nctrl, n, count int) error {
+	c.nctrl += nctrl
+	c.n += n
+	c.count += count
+	if c.count == c.size {
+		return c.emit()
+	}
+	return nil
+}
+
+// emit writes out the chunk that has been built, if there is one.
+func (c *chunkWriter) emit() error {
+	if c.count == 0 {
+		return nil
+	}
+	if err := writeChunk(c.w, c.count, c.ctrl[:c.nctrl], c.data[:c.n]); err != nil {
+		c.err = err
+		return err
+	}
+	c.nctrl, c.n, c.count = 0, 0, 0
+	return nil
+}
+
+// close does the work of Close, given the writer's Flush.
+func (c *chunkWriter) close(flush func() error) error {
+	if c.err == errWriterClosed {
+		return nil
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	c.err = errWriterClosed
+	if c.nrest > 0 {
+		return ErrPartialValue
+	}
+	return nil
+}
+
+// valueSplitter cuts the bytes given to Write into whole values, holding on
+// to the bytes of a value that is split across calls.
+type valueSplitter struct {
+	rest  [8]byte
+	nrest int
+}
+
+// split hands each whole width-byte value in p (after any bytes left over
+// from before) to put, keeping the bytes that don't make up a whole value
+// for next time.
+func (s *valueSplitter) split(p []byte, width int, put func(b []byte) error) (int, error) {
+	var ix int
+	for s.nrest > 0 && ix < len(p) {
+		s.rest[s.nrest] = p[ix]
+		s.nrest++
+		ix++
+		if s.nrest == width {
+			s.nrest = 0
+			if err := put(s.rest[:width]); err != nil {
+				return ix, err
+			}
+		}
+	}
+	for ; ix+width <= len(p); ix += width {
+		if err := put(p[ix : ix+width]); err != nil {
+			return ix, err
+		}
+	}
+	s.nrest += copy(s.rest[s.nrest:width], p[ix:])
+	return len(p), nil
+}
+
+// writeChunk writes a single chunk, made up of the header with the count of
+// values and the length of the data section, then the control and data
+// sections themselves.
+func writeChunk(w io.Writer, count int, ctrl, data []byte) error {
+	var hdr [2 * binary.MaxVarintLen64]byte
+	hlen := binary.PutUvarint(hdr[:], uint64(count))
+	hlen += binary.PutUvarint(hdr[hlen:], uint64(len(data)))
+	for _, b := range [][]byte{hdr[:hlen], ctrl, data} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
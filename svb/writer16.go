@@ -0,0 +1,107 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// Writer16 is the 16-bit counterpart to Writer. Its chunks have the same
+// header, followed by the control and data sections in the layout produced
+// by Encode16.
+type Writer16 struct {
+	chunkWriter
+
+	// The values that are not yet part of a whole block.
+	block   [8]uint16
+	pending int
+}
+
+// NewWriter16 returns a Writer16 that writes chunks of DefaultChunkSize
+// values to w.
+func NewWriter16(w io.Writer) *Writer16 {
+	return NewWriter16Size(w, DefaultChunkSize)
+}
+
+// NewWriter16Size returns a Writer16 that writes chunks of (at most) size
+// values to w. The size is rounded up to a multiple of 8.
+func NewWriter16Size(w io.Writer, size int) *Writer16 {
+	size = chunkSize(size, 8)
+	return &Writer16{
+		chunkWriter: newChunkWriter(w, size, ctrlLen16(size), 2*size),
+	}
+}
+
+// WriteUint16 adds a single value to the stream.
+func (z *Writer16) WriteUint16(v uint16) error {
+	if z.err != nil {
+		return z.err
+	}
+	z.block[z.pending] = v
+	z.pending++
+	if z.pending < 8 {
+		return nil
+	}
+
+	z.pending = 0
+	ctrl, n := PutU16Block(z.data[z.n:], z.block[:], false)
+	z.ctrl[z.nctrl] = ctrl
+	return z.added(1, n, 8)
+}
+
+// WriteUint16s adds all of vs to the stream.
+func (z *Writer16) WriteUint16s(vs []uint16) error {
+	for _, v := range vs {
+		if err := z.WriteUint16(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Write implements io.Writer, treating p as a sequence of little-endian
+// uint16 values. A value may be split across calls to Write.
+func (z *Writer16) Write(p []byte) (int, error) {
+	if z.err != nil {
+		return 0, z.err
+	}
+	return z.split(p, 2, func(b []byte) error {
+		return z.WriteUint16(binary.LittleEndian.Uint16(b))
+	})
+}
+
+// Flush writes any buffered values, including a trailing partial block of
+// fewer than 8 values, to the underlying io.Writer as a chunk.
+func (z *Writer16) Flush() error {
+	if z.err != nil {
+		return z.err
+	}
+	if z.pending > 0 {
+		tail := Encode16(nil, z.block[:z.pending])
+		z.nctrl += copy(z.ctrl[z.nctrl:], tail[:1])
+		z.n += copy(z.data[z.n:], tail[1:])
+		z.count += z.pending
+		z.pending = 0
+	}
+	return z.emit()
+}
+
+// Close flushes any buffered values. Further writes will return an error.
+// If the bytes given to Write did not end on a value boundary, Close returns
+// ErrPartialValue.
+func (z *Writer16) Close() error {
+	return z.close(z.Flush)
+}
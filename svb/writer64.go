@@ -0,0 +1,108 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// Writer64 is the 64-bit counterpart to Writer. Its chunks have the same
+// header, followed by the control and data sections in the layout produced
+// by Encode64.
+type Writer64 struct {
+	chunkWriter
+
+	// The values that are not yet part of a whole quad.
+	quad    [4]uint64
+	pending int
+}
+
+// NewWriter64 returns a Writer64 that writes chunks of DefaultChunkSize
+// values to w.
+func NewWriter64(w io.Writer) *Writer64 {
+	return NewWriter64Size(w, DefaultChunkSize)
+}
+
+// NewWriter64Size returns a Writer64 that writes chunks of (at most) size
+// values to w. The size is rounded up to a multiple of 4.
+func NewWriter64Size(w io.Writer, size int) *Writer64 {
+	size = chunkSize(size, 4)
+	return &Writer64{
+		chunkWriter: newChunkWriter(w, size, ctrlLen64(size), 8*size),
+	}
+}
+
+// WriteUint64 adds a single value to the stream.
+func (z *Writer64) WriteUint64(v uint64) error {
+	if z.err != nil {
+		return z.err
+	}
+	z.quad[z.pending] = v
+	z.pending++
+	if z.pending < 4 {
+		return nil
+	}
+
+	z.pending = 0
+	ctrl, n := PutU64Block(z.data[z.n:], z.quad[:], false)
+	z.ctrl[z.nctrl] = byte(ctrl >> 8)
+	z.ctrl[z.nctrl+1] = byte(ctrl)
+	return z.added(2, n, 4)
+}
+
+// WriteUint64s adds all of vs to the stream.
+func (z *Writer64) WriteUint64s(vs []uint64) error {
+	for _, v := range vs {
+		if err := z.WriteUint64(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Write implements io.Writer, treating p as a sequence of little-endian
+// uint64 values. A value may be split across calls to Write.
+func (z *Writer64) Write(p []byte) (int, error) {
+	if z.err != nil {
+		return 0, z.err
+	}
+	return z.split(p, 8, func(b []byte) error {
+		return z.WriteUint64(binary.LittleEndian.Uint64(b))
+	})
+}
+
+// Flush writes any buffered values, including a trailing partial quad, to
+// the underlying io.Writer as a chunk.
+func (z *Writer64) Flush() error {
+	if z.err != nil {
+		return z.err
+	}
+	if z.pending > 0 {
+		tail := Encode64(nil, z.quad[:z.pending])
+		z.nctrl += copy(z.ctrl[z.nctrl:], tail[:2])
+		z.n += copy(z.data[z.n:], tail[2:])
+		z.count += z.pending
+		z.pending = 0
+	}
+	return z.emit()
+}
+
+// Close flushes any buffered values. Further writes will return an error.
+// If the bytes given to Write did not end on a value boundary, Close returns
+// ErrPartialValue.
+func (z *Writer64) Close() error {
+	return z.close(z.Flush)
+}
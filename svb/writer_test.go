@@ -0,0 +1,105 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriterChunks(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriterSize(&buf, 4)
+	if err := w.WriteUint32s([]uint32{1024, 12, 10, 1073741824, 1, 2}); err != nil {
+		t.Fatalf("unexpected: %v\n", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected: %v\n", err)
+	}
+
+	expected := []byte{
+		0x04, 0x08, // 4 values, 8 data bytes
+		0x43, // control
+		0x04, 0x00, 0x0c, 0x0a, 0x40, 0x00, 0x00, 0x00,
+		0x02, 0x02, // 2 values, 2 data bytes
+		0x00, // control
+		0x01, 0x02,
+	}
+	if !bytes.Equal(buf.Bytes(), expected) {
+		t.Errorf("% x != % x\n", buf.Bytes(), expected)
+	}
+
+	if err := w.WriteUint32(3); err == nil {
+		t.Errorf("no error writing after Close\n")
+	}
+}
+
+func TestWriterWriteBytes(t *testing.T) {
+	vals := []uint32{1, 256, 65536, 16777216, 5}
+	raw := []byte{
+		0x01, 0x00, 0x00, 0x00,
+		0x00, 0x01, 0x00, 0x00,
+		0x00, 0x00, 0x01, 0x00,
+		0x00, 0x00, 0x00, 0x01,
+		0x05, 0x00, 0x00, 0x00,
+	}
+
+	var direct, split bytes.Buffer
+	w := NewWriter(&direct)
+	w.WriteUint32s(vals)
+	w.Close()
+
+	w = NewWriter(&split)
+	for _, p := range [][]byte{raw[:3], raw[3:9], raw[9:10], raw[10:]} {
+		if n, err := w.Write(p); n != len(p) || err != nil {
+			t.Errorf("write: %d, %v\n", n, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("unexpected: %v\n", err)
+	}
+	if !bytes.Equal(direct.Bytes(), split.Bytes()) {
+		t.Errorf("% x != % x\n", split.Bytes(), direct.Bytes())
+	}
+}
+
+func TestWriterPartialValue(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.Write([]byte{0x01, 0x00, 0x00, 0x00, 0x02})
+	if err := w.Close(); err != ErrPartialValue {
+		t.Errorf("%v != %v\n", err, ErrPartialValue)
+	}
+}
+
+func TestWriterFormat(t *testing.T) {
+	src := []uint32{1024, 12, 10, 1073741824, 1, 70000}
+	var buf bytes.Buffer
+	w := Reference.NewWriterSize(&buf, 4)
+	w.WriteUint32s(src)
+	w.Close()
+
+	// Each chunk holds exactly what Reference.Encode makes of its values.
+	var expected []byte
+	for _, chunk := range [][]uint32{src[:4], src[4:]} {
+		encoded := Reference.Encode(nil, chunk)
+		clen := (len(chunk) + 3) / 4
+		expected = append(expected, byte(len(chunk)), byte(len(encoded)-clen))
+		expected = append(expected, encoded...)
+	}
+	if !bytes.Equal(buf.Bytes(), expected) {
+		t.Errorf("% x != % x\n", buf.Bytes(), expected)
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+// zigzag maps signed values onto unsigned ones so that numbers close to
+// zero, whether positive or negative, end up small: 0, -1, 1, -2, 2, ...
+// become 0, 1, 2, 3, 4, ...
+func zigzag(n int32) uint32 {
+	return uint32(n<<1) ^ uint32(n>>31)
+}
+
+// unzigzag reverses zigzag.
+func unzigzag(n uint32) int32 {
+	return int32(n>>1) ^ -int32(n&1)
+}
+
+// PutI32Block encodes a single quad of int32 values. It is the signed
+// counterpart to PutU32Block: each value is zigzag mapped first, so that
+// small negative numbers take as few bytes as small positive ones.
+//
+// When diff is set, the differences between consecutive values (which may
+// be negative) are what get zigzag mapped, so the values don't need to be
+// in sorted order.
+//
+// Panics will be thrown if there are too few bytes available in the data
+// buffer, or too few values in the quad buffer.
+func PutI32Block(data []byte, quad []int32, diff bool) (ctrl byte, n int) {
+	return Legacy.PutI32Block(data, quad, diff)
+}
+
+// PutI32Block is the Format-specific version of the package-level
+// PutI32Block.
+func (f Format) PutI32Block(data []byte, quad []int32, diff bool) (ctrl byte, n int) {
+	var zz [4]uint32
+	var prev int32
+	for ix, num := range quad[:4] {
+		if diff {
+			num, prev = num-prev, num
+		}
+		zz[ix] = zigzag(num)
+	}
+	return f.PutU32Block(data, zz[:], false)
+}
+
+// GetI32Block is the read-side parallel to PutI32Block.
+//
+// Panics will be thrown if there are too few bytes available in the data
+// buffer.
+func GetI32Block(ctrl byte, data []byte, diff bool) (quad [4]int32, n int) {
+	return Legacy.GetI32Block(ctrl, data, diff)
+}
+
+// GetI32Block is the Format-specific version of the package-level
+// GetI32Block.
+func (f Format) GetI32Block(ctrl byte, data []byte, diff bool) (quad [4]int32, n int) {
+	zz, n := f.GetU32Block(ctrl, data, false)
+	var prev int32
+	for ix, num := range zz {
+		quad[ix] = unzigzag(num)
+		if diff {
+			prev += quad[ix]
+			quad[ix] = prev
+		}
+	}
+	return quad, n
+}
+
+// EncodeInt32 is the signed counterpart to Encode, zigzag mapping each
+// value before it is encoded.
+func EncodeInt32(dst []byte, src []int32) []byte {
+	return Legacy.EncodeInt32(dst, src)
+}
+
+// EncodeInt32 is the Format-specific version of the package-level
+// EncodeInt32.
+func (f Format) EncodeInt32(dst []byte, src []int32) []byte {
+	zz := make([]uint32, len(src))
+	for ix, num := range src {
+		zz[ix] = zigzag(num)
+	}
+	return f.Encode(dst, zz)
+}
+
+// DecodeInt32 is the read-side parallel to EncodeInt32.
+func DecodeInt32(dst []int32, src []byte, count int) ([]int32, error) {
+	return Legacy.DecodeInt32(dst, src, count)
+}
+
+// DecodeInt32 is the Format-specific version of the package-level
+// DecodeInt32.
+func (f Format) DecodeInt32(dst []int32, src []byte, count int) ([]int32, error) {
+	zz, err := f.Decode(nil, src, count)
+	if err != nil {
+		return nil, err
+	}
+	if cap(dst) < count {
+		dst = make([]int32, count)
+	} else {
+		dst = dst[:count]
+	}
+	for ix, num := range zz {
+		dst[ix] = unzigzag(num)
+	}
+	return dst, nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2017 Nelz
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package svb
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestZigzag(t *testing.T) {
+	tests := []struct {
+		signed   int32
+		unsigned uint32
+	}{
+		{0, 0},
+		{-1, 1},
+		{1, 2},
+		{-2, 3},
+		{2, 4},
+		{math.MaxInt32, math.MaxUint32 - 1},
+		{math.MinInt32, math.MaxUint32},
+	}
+
+	for _, test := range tests {
+		if zz := zigzag(test.signed); zz != test.unsigned {
+			t.Errorf("zigzag(%d): %d != %d\n", test.signed, zz, test.unsigned)
+		}
+		if n := unzigzag(test.unsigned); n != test.signed {
+			t.Errorf("unzigzag(%d): %d != %d\n", test.unsigned, n, test.signed)
+		}
+	}
+}
+
+func TestI32Block(t *testing.T) {
+	tests := []struct {
+		quad []int32
+		diff bool
+		ctrl byte
+		size int
+	}{
+		{ // Small negatives cost a single byte
+			[]int32{-1, -64, 63, 0},
+			false,
+			0x00,
+			4,
+		},
+		{ // Unsorted values, with differences of -3, 203, -200, 1
+			[]int32{-3, 200, 0, 1},
+			true,
+			0x14,
+			6,
+		},
+	}
+
+	for _, test := range tests {
+		data := make([]byte, 16)
+		ctrl, size := PutI32Block(data, test.quad, test.diff)
+		if ctrl != test.ctrl {
+			t.Errorf("ctrl mismatch: %x != %x\n", ctrl, test.ctrl)
+		}
+		if size != test.size {
+			t.Errorf("size mismatch: %d != %d\n", size, test.size)
+		}
+
+		quad, size := GetI32Block(ctrl, data, test.diff)
+		if size != test.size {
+			t.Errorf("size mismatch: %d != %d\n", size, test.size)
+		}
+		for ix := range test.quad {
+			if quad[ix] != test.quad[ix] {
+				t.Errorf("mismatch: %v != %v\n", quad, test.quad)
+				break
+			}
+		}
+	}
+}
+
+func TestInt32Roundtrip(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for _, f := range []Format{Legacy, Reference} {
+		for count := 0; count < 50; count++ {
+			src := make([]int32, count)
+			for ix := range src {
+				src[ix] = int32(r.Uint32()) >> uint(8*r.Intn(4))
+			}
+
+			vals, err := f.DecodeInt32(nil, f.EncodeInt32(nil, src), count)
+			if err != nil {
+				t.Errorf("%v: unexpected: %v\n", f, err)
+				continue
+			}
+			for ix := range src {
+				if vals[ix] != src[ix] {
+					t.Errorf("%v: mismatch: %v != %v\n", f, vals, src)
+					break
+				}
+			}
+		}
+	}
+}